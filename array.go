@@ -17,6 +17,7 @@ limitations under the License.
 package ag
 
 import (
+	"bytes"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -58,10 +59,44 @@ func (_ NullArrayError) Error() string {
 	return "NULL"
 }
 
+// nullElementValue is the representation of NULL elements written by
+// AgensGraph. It is read case-insensitively, as array_in does.
 var nullElementValue = []byte("NULL")
 
+// isNullElement reports whether b is a NULL element.
+func isNullElement(b []byte) bool {
+	return bytes.EqualFold(b, nullElementValue)
+}
+
+// hasNullPrefix reports whether b begins with a NULL element. NULL must be
+// followed by the end of b or a delimiter so that an element whose label
+// begins with "NULL" is not taken for NULL.
+func hasNullPrefix(b []byte) bool {
+	n := len(nullElementValue)
+	if len(b) < n || !isNullElement(b[:n]) {
+		return false
+	}
+	if len(b) == n {
+		return true
+	}
+	switch b[n] {
+	case ',', ']', '}':
+		return true
+	}
+	return false
+}
+
+// trimBrackets returns the elements of the array b of the type named name
+// without the surrounding brackets.
+func trimBrackets(b []byte, name string) ([]byte, error) {
+	if len(b) < 2 || b[0] != '[' || b[len(b)-1] != ']' {
+		return nil, fmt.Errorf("bad %s array representation: %s", name, b)
+	}
+	return b[1 : len(b)-1], nil
+}
+
 type elementsReader interface {
-	readElements(b []byte) ([]interface{}, error)
+	readElements(b []byte, o scanOptions) ([]interface{}, error)
 }
 
 type elementArray struct {
@@ -122,7 +157,7 @@ func (a elementArray) Scan(src interface{}) error {
 	}
 
 	reader := reflect.Zero(rte).Interface().(elementsReader)
-	ds, err := reader.readElements(b)
+	ds, err := reader.readElements(b, scanOptions{})
 	if err != nil {
 		return errors.New("failed to read elements: " + err.Error())
 	}
@@ -153,6 +188,168 @@ func (a elementArray) Scan(src interface{}) error {
 	return nil
 }
 
+var typeEntity = reflect.TypeOf((*Entity)(nil)).Elem()
+
+// ScanEntities reads an array of vertices or edges from src and stores the
+// result in out, which must be a pointer to a slice of entities. Unlike Array,
+// the element type only needs to be an entity; it need not implement
+// database/sql Scanner. opts are applied to each element as ScanEntity does.
+//
+// If src is nil, out is set to nil.
+func ScanEntities(src interface{}, out interface{}, opts ...ScanOption) error {
+	_, err := tracedScanEntities(src, out, newScanOptions(opts), false)
+	return err
+}
+
+// ScanEntitiesPartial is like ScanEntities, but it does not stop at an element
+// that cannot be stored. Such elements are left as zero values in out, and
+// errs[i] is the error for the i-th element. errs is nil if all the elements
+// are stored.
+//
+// err is not nil only if out is not valid or src is not a valid array, in
+// which case out is left unchanged.
+func ScanEntitiesPartial(src interface{}, out interface{}, opts ...ScanOption) (errs []error, err error) {
+	return tracedScanEntities(src, out, newScanOptions(opts), true)
+}
+
+func tracedScanEntities(src interface{}, out interface{}, o scanOptions, partial bool) (errs []error, err error) {
+	err = o.traced("ag.ScanEntities", src, out, func() error {
+		errs, err = scanEntities(src, out, o, partial)
+		return err
+	})
+	return
+}
+
+func scanEntities(src interface{}, out interface{}, o scanOptions, partial bool) (errs []error, err error) {
+	// *[]t
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("%T is not a pointer to slice", out)
+	}
+	if rv.IsNil() {
+		return nil, fmt.Errorf("%T is nil", out)
+	}
+
+	// []t
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%T is not a pointer to slice", out)
+	}
+	rt := rv.Type()
+
+	// *t.(Entity)
+	rte := rt.Elem()
+	if !reflect.PtrTo(rte).Implements(typeEntity) {
+		return nil, fmt.Errorf("%s does not implement %s", reflect.PtrTo(rte), typeEntity)
+	}
+
+	if src == nil {
+		rv.Set(reflect.Zero(rt))
+		return nil, nil
+	}
+
+	b, ok := textSource(src)
+	if !ok {
+		return nil, o.reportError(fmt.Errorf("invalid source for %s: %T", rt, src), nil)
+	}
+	o.countBytes(len(b))
+	if len(b) < 1 {
+		return nil, o.reportError(fmt.Errorf("invalid source for %s: %v", rt, b), b)
+	}
+
+	reader := reflect.New(rte).Interface().(elementsReader)
+	ds, err := reader.readElements(b, o)
+	if err != nil {
+		return nil, o.reportError(errors.New("failed to read elements: "+err.Error()), b)
+	}
+
+	n := len(ds)
+	es := reflect.MakeSlice(rt, n, n)
+	for i := 0; i < n; i++ {
+		e := es.Index(i).Addr().Interface().(Entity)
+		err := scanEntity(ds[i], e, o)
+		if err == nil {
+			continue
+		}
+
+		err = errors.New("invalid element: " + err.Error())
+		if !partial {
+			return nil, err
+		}
+		if errs == nil {
+			errs = make([]error, n)
+		}
+		errs[i] = err
+		es.Index(i).Set(reflect.Zero(rte))
+	}
+
+	rv.Set(es)
+	return errs, nil
+}
+
+// ScanMixedArray reads an array whose elements may be vertices or edges, such
+// as the result of a Cypher list of nodes and relationships. Each element of
+// the result is BasicVertex, BasicEdge, or nil for NULL, in the same order as
+// in src. opts are applied to each element as ScanEntity does.
+//
+// If src is nil, the result is nil.
+func ScanMixedArray(src interface{}, opts ...ScanOption) ([]interface{}, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	o := newScanOptions(opts)
+
+	b, ok := textSource(src)
+	if !ok {
+		return nil, o.reportError(fmt.Errorf("invalid source for array: %T", src), nil)
+	}
+	elems, err := trimBrackets(b, "mixed")
+	if err != nil {
+		return nil, o.reportError(err, b)
+	}
+	b = elems
+
+	es := []interface{}{}
+	for len(b) > 0 {
+		if len(es) > 0 {
+			if b[0] != ',' {
+				return nil, o.reportError(fmt.Errorf("bad mixed array representation: %s", b), b)
+			}
+			// remove comma
+			b = b[1:]
+		}
+
+		advance, e, err := readMixedElement(b, o)
+		if err != nil {
+			return nil, errors.New("invalid element: " + err.Error())
+		}
+		es = append(es, e)
+		b = b[advance:]
+	}
+	return es, nil
+}
+
+// readMixedElement reads a vertex or an edge from the beginning of b. An
+// element is an edge if the ID is followed by the IDs of the start and end
+// vertices.
+func readMixedElement(b []byte, o scanOptions) (advance int, e interface{}, err error) {
+	if hasNullPrefix(b) {
+		return len(nullElementValue), nil, nil
+	}
+
+	m := vertexCoreRegexp.FindIndex(b)
+	if m != nil && m[1] < len(b) && b[m[1]] == '[' {
+		var ed BasicEdge
+		advance, err = scanEntityN(b, &ed, o)
+		return advance, ed, err
+	}
+
+	var v BasicVertex
+	advance, err = scanEntityN(b, &v, o)
+	return advance, v, err
+}
+
 func (a elementArray) Value() (driver.Value, error) {
 	return nil, fmt.Errorf("Value() on an array of %T is not supported", a.dest)
 }
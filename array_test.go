@@ -20,7 +20,7 @@ import "testing"
 
 type testElement struct{}
 
-func (_ testElement) readElements(b []byte) ([]interface{}, error) {
+func (_ testElement) readElements(b []byte, o scanOptions) ([]interface{}, error) {
 	return []interface{}{}, nil
 }
 
@@ -106,3 +106,79 @@ func TestArrayValue(t *testing.T) {
 		t.Errorf("error expected for Value() on Array")
 	}
 }
+
+func TestHasNullPrefix(t *testing.T) {
+	tests := []struct {
+		b    string
+		null bool
+	}{
+		{"NULL", true},
+		{"null", true},
+		{"Null", true},
+		{"NULL,v[3.1]{}", true},
+		{"NULL]", true},
+		{"NULL}", true},
+		{"NUL", false},
+		{"NULLx[3.1]{}", false},
+		{"v[3.1]{}", false},
+	}
+	for _, c := range tests {
+		if null := hasNullPrefix([]byte(c.b)); null != c.null {
+			t.Errorf("got hasNullPrefix(%q) == %t, want %t", c.b, null, c.null)
+		}
+	}
+}
+
+func TestScanMixedArray(t *testing.T) {
+	es, err := ScanMixedArray([]byte(`[person[3.1]{"name": "x[1.1]"},knows[4.1][3.1,3.2]{},NULL,person[3.2]{}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 4 {
+		t.Fatalf("got %d elements, want 4", len(es))
+	}
+
+	if v, ok := es[0].(BasicVertex); !ok {
+		t.Errorf("got %T, want BasicVertex", es[0])
+	} else if v.Id.String() != "3.1" || v.Properties["name"] != "x[1.1]" {
+		t.Errorf("got %s, want person[3.1]", v)
+	}
+	if e, ok := es[1].(BasicEdge); !ok {
+		t.Errorf("got %T, want BasicEdge", es[1])
+	} else if e.Id.String() != "4.1" || e.End.String() != "3.2" {
+		t.Errorf("got %s, want knows[4.1][3.1,3.2]", e)
+	}
+	if es[2] != nil {
+		t.Errorf("got %v, want nil", es[2])
+	}
+	if _, ok := es[3].(BasicVertex); !ok {
+		t.Errorf("got %T, want BasicVertex", es[3])
+	}
+
+	es, err = ScanMixedArray([]byte(`[]`))
+	if err != nil || es == nil || len(es) != 0 {
+		t.Errorf("got %v, %v, want empty", es, err)
+	}
+	es, err = ScanMixedArray(nil)
+	if err != nil || es != nil {
+		t.Errorf("got %v, %v, want nil", es, err)
+	}
+}
+
+func TestScanMixedArrayError(t *testing.T) {
+	tests := []interface{}{
+		0,
+		[]byte(``),
+		[]byte(`[`),
+		[]byte(`[v[3.1]{}`),
+		[]byte(`[v[3.1]{}e[4.1][3.1,3.2]{}]`),
+		[]byte(`[v[3.1]{},]`),
+		[]byte(`[e[4.1][3.1]{}]`),
+	}
+	for _, src := range tests {
+		_, err := ScanMixedArray(src)
+		if err == nil {
+			t.Errorf("error expected for %v", src)
+		}
+	}
+}
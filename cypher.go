@@ -0,0 +1,148 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// QuoteCypherString returns s as a single-quoted Cypher string literal that
+// can be safely inlined in a query.
+//
+// Single quotes and backslashes are escaped with a backslash. Backspace, form
+// feed, newline, carriage return, and tab are written as \b, \f, \n, \r, and
+// \t respectively, and the other control characters are written as \uXXXX.
+func QuoteCypherString(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('\'')
+
+	return b.String()
+}
+
+// IdInClause returns a Cypher fragment that matches the entity bound to the
+// variable alias by its ID against ids, and the arguments for the fragment.
+//
+// AgensGraph can take a list of graphids as a single _graphid parameter, so
+// ids are passed as an argument (see Array) instead of being inlined in the
+// fragment. The fragment refers to the argument as $1, so the fragment must be
+// used in a query that has no other parameters, or args must be renumbered
+// by the caller. alias is inserted as is and must be a valid variable name.
+//
+//	clause, args := IdInClause("v", ids)
+//	rows, err := db.Query("MATCH (v) WHERE "+clause+" RETURN v", args...)
+func IdInClause(alias string, ids []GraphId) (string, []interface{}) {
+	if ids == nil {
+		// NULL would match nothing
+		ids = []GraphId{}
+	}
+	return "id(" + alias + ") IN $1", []interface{}{Array(ids)}
+}
+
+// CreateVertexStatement returns a Cypher statement that creates a vertex with
+// label and props, and the arguments for the statement.
+//
+// AgensGraph does not allow parameters for labels, so label is quoted as an
+// identifier and inlined in the statement. props is passed as a single jsonb
+// parameter, $1, which AgensGraph takes as the properties with the "=" form
+// of a property map. props is encoded by MarshalProperties when the
+// statement is executed; a nil props is an empty object.
+//
+//	stmt, args := CreateVertexStatement("person", props)
+//	_, err := db.Exec(stmt, args...)
+func CreateVertexStatement(label string, props map[string]interface{}) (string, []interface{}) {
+	return "CREATE (:" + quoteIdentifier(label) + " =$1)", []interface{}{propertiesValue{props}}
+}
+
+// CreateEdgeStatement returns a Cypher statement that creates an edge with
+// label and props from the vertex whose ID is start to the vertex whose ID is
+// end, and the arguments for the statement. The vertices are matched by their
+// IDs given as $1 and $2, and props is given as $3 as for
+// CreateVertexStatement. The statement creates nothing if either of the
+// vertices does not exist.
+func CreateEdgeStatement(label string, start, end GraphId, props map[string]interface{}) (string, []interface{}) {
+	stmt := "MATCH (s), (e) WHERE id(s) = $1 AND id(e) = $2 CREATE (s)-[:" + quoteIdentifier(label) + " =$3]->(e)"
+	return stmt, []interface{}{start, end, propertiesValue{props}}
+}
+
+// MergeVertexStatement returns a Cypher statement that merges a vertex with
+// label on keyProps and sets setProps on it, and the arguments for the
+// statement. It is the common pattern of idempotent upserts:
+//
+//	MERGE (v:"label" =$1) SET v += $2
+//
+// keyProps is given as $1 and matches an existing vertex or becomes the
+// properties of a new one. setProps is given as $2 and merged into the
+// properties of the vertex either way. Both are passed as jsonb parameters as
+// for CreateVertexStatement. The SET clause is omitted if setProps is empty.
+//
+// keyProps should identify at most one vertex; an empty keyProps matches every
+// vertex with label.
+func MergeVertexStatement(label string, keyProps, setProps map[string]interface{}) (string, []interface{}) {
+	stmt := "MERGE (v:" + quoteIdentifier(label) + " =$1)"
+	args := []interface{}{propertiesValue{keyProps}}
+	if len(setProps) > 0 {
+		stmt += " SET v += $2"
+		args = append(args, propertiesValue{setProps})
+	}
+	return stmt, args
+}
+
+// propertiesValue is a database/sql/driver Valuer for properties.
+type propertiesValue struct {
+	props map[string]interface{}
+}
+
+func (v propertiesValue) Value() (driver.Value, error) {
+	if v.props == nil {
+		return "{}", nil
+	}
+	b, err := MarshalProperties(v.props)
+	if err != nil {
+		return nil, errors.New("invalid properties: " + err.Error())
+	}
+	return string(b), nil
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestQuoteCypherString(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", `''`},
+		{"go", `'go'`},
+		{"it's", `'it\'s'`},
+		{`a\b`, `'a\\b'`},
+		{`"quoted"`, `'"quoted"'`},
+		{"a\nb\tc\r\b\f", `'a\nb\tc\r\b\f'`},
+		{"\x00\x1f\x7f", `'\u0000\u001f\u007f'`},
+		{"한글", `'한글'`},
+	}
+	for _, c := range tests {
+		if q := QuoteCypherString(c.s); q != c.want {
+			t.Errorf("got %s, want %s", q, c.want)
+		}
+	}
+}
+
+func TestIdInClause(t *testing.T) {
+	ids := []GraphId{mustNewGraphId("3.1"), mustNewGraphId("3.2")}
+	clause, args := IdInClause("v", ids)
+	if want := "id(v) IN $1"; clause != want {
+		t.Errorf("got %q, want %q", clause, want)
+	}
+	if len(args) != 1 {
+		t.Fatalf("got %d args, want 1", len(args))
+	}
+
+	val, err := args[0].(driver.Valuer).Value()
+	if err != nil {
+		t.Error(err)
+	} else if b, ok := val.([]byte); !ok || string(b) != "{3.1,3.2}" {
+		t.Errorf("got %v, want {3.1,3.2}", val)
+	}
+
+	_, args = IdInClause("v", nil)
+	val, err = args[0].(driver.Valuer).Value()
+	if err != nil {
+		t.Error(err)
+	} else if b, ok := val.([]byte); !ok || string(b) != "{}" {
+		t.Errorf("got %v, want {}", val)
+	}
+}
+
+func TestCreateVertexStatement(t *testing.T) {
+	stmt, args := CreateVertexStatement(`my"label`, map[string]interface{}{"name": "a < b", "n": 1})
+	if want := `CREATE (:"my""label" =$1)`; stmt != want {
+		t.Errorf("got %s, want %s", stmt, want)
+	}
+	if len(args) != 1 {
+		t.Fatalf("got %d args, want 1", len(args))
+	}
+	v, err := args[0].(driver.Valuer).Value()
+	if err != nil {
+		t.Error(err)
+	} else if want := `{"n":1,"name":"a < b"}`; v != want {
+		t.Errorf("got %v, want %s", v, want)
+	}
+
+	_, args = CreateVertexStatement("v", nil)
+	if v, _ := args[0].(driver.Valuer).Value(); v != "{}" {
+		t.Errorf("got %v, want {}", v)
+	}
+
+	_, args = CreateVertexStatement("v", map[string]interface{}{"c": make(chan int)})
+	if _, err := args[0].(driver.Valuer).Value(); err == nil {
+		t.Error("error expected for invalid properties")
+	}
+}
+
+func TestCreateEdgeStatement(t *testing.T) {
+	start, end := mustNewGraphId("3.1"), mustNewGraphId("3.2")
+	stmt, args := CreateEdgeStatement("knows", start, end, map[string]interface{}{"since": 2020})
+	if want := `MATCH (s), (e) WHERE id(s) = $1 AND id(e) = $2 CREATE (s)-[:"knows" =$3]->(e)`; stmt != want {
+		t.Errorf("got %s, want %s", stmt, want)
+	}
+	if len(args) != 3 || args[0] != start || args[1] != end {
+		t.Fatalf("got %v, want [3.1 3.2 props]", args)
+	}
+	if v, err := args[2].(driver.Valuer).Value(); err != nil || v != `{"since":2020}` {
+		t.Errorf("got %v, %v, want {\"since\":2020}", v, err)
+	}
+}
+
+func TestMergeVertexStatement(t *testing.T) {
+	stmt, args := MergeVertexStatement("person", map[string]interface{}{"id": 1}, map[string]interface{}{"name": "a"})
+	if want := `MERGE (v:"person" =$1) SET v += $2`; stmt != want {
+		t.Errorf("got %s, want %s", stmt, want)
+	}
+	want := []string{`{"id":1}`, `{"name":"a"}`}
+	if len(args) != len(want) {
+		t.Fatalf("got %d args, want %d", len(args), len(want))
+	}
+	for i, a := range args {
+		if v, err := a.(driver.Valuer).Value(); err != nil || v != want[i] {
+			t.Errorf("got %v, %v, want %s", v, err, want[i])
+		}
+	}
+
+	stmt, args = MergeVertexStatement("person", map[string]interface{}{"id": 1}, nil)
+	if want := `MERGE (v:"person" =$1)`; stmt != want || len(args) != 1 {
+		t.Errorf("got %s with %d args, want %s with 1", stmt, len(args), want)
+	}
+}
+
+func TestServerCreateStatement(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	db := mustOpenAndSetGraph(t)
+	defer db.Close()
+
+	stmt, args := CreateVertexStatement("csv", map[string]interface{}{"name": "a"})
+	_, err := db.Exec(stmt, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, args = CreateVertexStatement("csv", map[string]interface{}{"name": "b"})
+	_, err = db.Exec(stmt, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var a, b GraphId
+	err = db.QueryRow(`MATCH (a:csv {name: 'a'}), (b:csv {name: 'b'}) RETURN id(a), id(b)`).Scan(&a, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, args = CreateEdgeStatement("cse", a, b, map[string]interface{}{"w": 1})
+	_, err = db.Exec(stmt, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var e BasicEdge
+	err = db.QueryRow(`MATCH (:csv)-[r:cse]->(:csv) RETURN r`).Scan(&e)
+	if err != nil {
+		t.Error(err)
+	} else if !e.Start.Equal(a) || !e.End.Equal(b) || e.Properties["w"] != float64(1) {
+		t.Errorf("got %s, want cse from %s to %s", e, a, b)
+	}
+}
+
+func TestServerIdInClause(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	db := mustOpenAndSetGraph(t)
+	defer db.Close()
+
+	_, err := db.Exec(`CREATE (:iic {n: 1}), (:iic {n: 2}), (:iic {n: 3})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var a, b GraphId
+	err = db.QueryRow(`MATCH (a:iic {n: 1}), (b:iic {n: 2}) RETURN id(a), id(b)`).Scan(&a, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ids  []GraphId
+		want int
+	}{
+		{[]GraphId{a, b}, 2},
+		{[]GraphId{b}, 1},
+		{[]GraphId{}, 0},
+		{nil, 0},
+	}
+	for _, c := range tests {
+		clause, args := IdInClause("v", c.ids)
+		var n int
+		err = db.QueryRow("MATCH (v:iic) WHERE "+clause+" RETURN count(v)", args...).Scan(&n)
+		if err != nil {
+			t.Errorf("%v: %v", c.ids, err)
+		} else if n != c.want {
+			t.Errorf("got %d vertices for %v, want %d", n, c.ids, c.want)
+		}
+	}
+}
+
+func TestServerMergeVertexStatement(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	db := mustOpenAndSetGraph(t)
+	defer db.Close()
+
+	for _, name := range []string{"a", "b"} {
+		stmt, args := MergeVertexStatement("mvs", map[string]interface{}{"k": 1}, map[string]interface{}{"name": name})
+		_, err := db.Exec(stmt, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var n int
+	err := db.QueryRow(`MATCH (v:mvs) RETURN count(v)`).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("got %d vertices, want 1", n)
+	}
+
+	var v BasicVertex
+	err = db.QueryRow(`MATCH (v:mvs) RETURN v LIMIT 1`).Scan(&v)
+	if err != nil {
+		t.Error(err)
+	} else if v.Properties["name"] != "b" {
+		t.Errorf("got %s, want name b", v)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import "sync/atomic"
+
+// Decoder reads entities with a fixed set of options. Creating a Decoder once
+// and reusing it avoids processing the options on every call in a loop.
+//
+// A Decoder holds the options, which never change after it is created, and
+// the counters for Stats, which are updated atomically, so it is safe for
+// concurrent use.
+type Decoder struct {
+	o scanOptions
+}
+
+// NewDecoder returns a Decoder that applies opts to every call.
+func NewDecoder(opts ...ScanOption) *Decoder {
+	o := newScanOptions(opts)
+	o.stats = &decoderStats{}
+	return &Decoder{o}
+}
+
+// DecoderStats is the amount of input a Decoder has processed.
+type DecoderStats struct {
+	Bytes    int64 // length of the text read by all the calls
+	Elements int64 // number of non-NULL vertices and edges read, as for OnEntity
+}
+
+type decoderStats struct {
+	bytes    atomic.Int64
+	elements atomic.Int64
+}
+
+// Stats returns the amount of input dec has processed since it was created,
+// including the input of calls that failed. It is meant for profiling payload
+// sizes; the package level functions do not count anything.
+func (dec *Decoder) Stats() DecoderStats {
+	if dec.o.stats == nil {
+		return DecoderStats{}
+	}
+	return DecoderStats{dec.o.stats.bytes.Load(), dec.o.stats.elements.Load()}
+}
+
+// ScanEntity is like the package level ScanEntity with the options of dec.
+func (dec *Decoder) ScanEntity(src interface{}, entity Entity) error {
+	return tracedScanEntity(src, entity, dec.o)
+}
+
+// ScanEntityN is like the package level ScanEntityN with the options of dec.
+func (dec *Decoder) ScanEntityN(b []byte, entity Entity) (advance int, err error) {
+	return tracedScanEntityN(b, entity, dec.o)
+}
+
+// ScanEntities is like the package level ScanEntities with the options of dec.
+func (dec *Decoder) ScanEntities(src interface{}, out interface{}) error {
+	_, err := tracedScanEntities(src, out, dec.o, false)
+	return err
+}
+
+// ScanPath is like the package level ScanPath with the options of dec.
+func (dec *Decoder) ScanPath(src interface{}, saver PathSaver) error {
+	return tracedScanPath(src, saver, dec.o)
+}
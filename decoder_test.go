@@ -0,0 +1,419 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"testing"
+	"unsafe"
+)
+
+type numberVertex struct {
+	VertexHeader `json:"-"`
+	Count        interface{}
+}
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(Strict(), UseNumber())
+
+	var v numberVertex
+	err := dec.ScanEntity([]byte(`v[3.1]{"count": 9007199254740993}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := v.Count.(json.Number); !ok || n != "9007199254740993" {
+		t.Errorf("got %#v, want json.Number 9007199254740993", v.Count)
+	}
+
+	err = dec.ScanEntity([]byte(`v[3.1]{"count": 1, "count": 2}`), &v)
+	if err == nil {
+		t.Error("error expected for duplicate keys in strict mode")
+	}
+
+	n, err := dec.ScanEntityN([]byte(`v[3.1]{"count": 1},v[3.2]{}`), &v)
+	if err != nil {
+		t.Error(err)
+	} else if want := len(`v[3.1]{"count": 1}`); n != want {
+		t.Errorf("got %d, want %d", n, want)
+	}
+
+	var vs []numberVertex
+	err = dec.ScanEntities([]byte(`[v[3.1]{"count": 1},v[3.2]{"count": 2}]`), &vs)
+	if err != nil {
+		t.Error(err)
+	} else if len(vs) != 2 || vs[1].Count != json.Number("2") {
+		t.Errorf("got %v, want 2 vertices", vs)
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		label string
+		valid bool
+	}{
+		{"person", true},
+		{"_Person$1", true},
+		{"사람", true},
+		{"social.person", true},
+		{`"my label"`, true},
+		{`social."my ""label"""`, true},
+		{"1person", false},
+		{"per son", false},
+		{"a.b.c", false},
+		{`"open`, false},
+		{"person;DROP", false},
+	}
+	dec := NewDecoder(ValidateLabels(nil))
+	for _, c := range tests {
+		var v BasicVertex
+		err := dec.ScanEntity([]byte(c.label+"[3.1]{}"), &v)
+		if c.valid && err != nil {
+			t.Errorf("%s: %v", c.label, err)
+		} else if !c.valid && err == nil {
+			t.Errorf("error expected for %s", c.label)
+		}
+
+		err = ScanEntity([]byte(c.label+"[3.1]{}"), &v)
+		if err != nil {
+			t.Errorf("%s: %v", c.label, err)
+		}
+	}
+
+	dec = NewDecoder(ValidateLabels(regexp.MustCompile(`^(person|knows)$`)), SkipProperties())
+	var e BasicEdge
+	if err := dec.ScanEntity([]byte(`knows[4.1][3.1,3.2]{}`), &e); err != nil {
+		t.Error(err)
+	}
+	if err := dec.ScanEntity([]byte(`likes[4.1][3.1,3.2]{}`), &e); err == nil {
+		t.Error("error expected for likes")
+	}
+}
+
+func TestUseNumber(t *testing.T) {
+	var v numberVertex
+	err := ScanEntity([]byte(`v[3.1]{"count": 1}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.Count.(float64); !ok {
+		t.Errorf("got %T, want float64", v.Count)
+	}
+
+	err = ScanEntity([]byte(`v[3.1]{"count": 1}`), &v, UseNumber())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.Count.(json.Number); !ok {
+		t.Errorf("got %T, want json.Number", v.Count)
+	}
+}
+
+type requiredNameVertex struct {
+	VertexHeader
+	Name string `json:"name"`
+}
+
+func (v *requiredNameVertex) RequiredProperties() []string {
+	return []string{"name"}
+}
+
+func TestLowercaseKeys(t *testing.T) {
+	dec := NewDecoder(LowercaseKeys())
+
+	var v BasicVertex
+	err := dec.ScanEntity([]byte(`v[3.1]{"Name": "a", "AGE": 1, "N\u00C9": 2, "o": {"Key": "Val"}}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"name": "a", "age": float64(1), "n\u00e9": float64(2), "o": map[string]interface{}{"Key": "Val"}}
+	if !reflect.DeepEqual(v.Properties, want) {
+		t.Errorf("got %v, want %v", v.Properties, want)
+	}
+
+	// RequiredProperties matches the keys case-sensitively.
+	for _, b := range []string{`v[3.1]{"Name": "a"}`, `v[3.1]{"NAME": "a"}`} {
+		var r requiredNameVertex
+		if err := ScanEntity([]byte(b), &r); err == nil {
+			t.Errorf("error expected for %s without LowercaseKeys", b)
+		}
+	}
+
+	for _, b := range []string{`v[3.1]{"Name": "a"}`, `v[3.1]{"NAME": "a"}`, `v[3.1]{"name": "b", "Name": "a"}`} {
+		var r requiredNameVertex
+		err = dec.ScanEntity([]byte(b), &r)
+		if err != nil {
+			t.Error(err)
+		} else if r.Name != "a" {
+			t.Errorf("got %q for %s, want a", r.Name, b)
+		}
+	}
+}
+
+func TestDecoderStats(t *testing.T) {
+	dec := NewDecoder()
+
+	v := `v[3.1]{}`
+	var bv BasicVertex
+	if err := dec.ScanEntity([]byte(v), &bv); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.ScanEntity(nil, &bv); err != nil {
+		t.Fatal(err)
+	}
+	vs := "[" + v + ",NULL," + v + "]"
+	var bvs []BasicVertex
+	if err := dec.ScanEntities([]byte(vs), &bvs); err != nil {
+		t.Fatal(err)
+	}
+	p := `[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`
+	var bp BasicPath
+	if err := dec.ScanPath([]byte(p), &bp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.ScanEntityN([]byte(v+"rest"), &bv); err != nil {
+		t.Fatal(err)
+	}
+	_ = dec.ScanEntity([]byte(`v[3.1]{"bad"}`), &bv)
+
+	want := DecoderStats{
+		Bytes: int64(len(v) + len(vs) + len(p) + len(v) + len(`v[3.1]{"bad"}`)),
+		// the bad vertex is read before its properties fail
+		Elements: 1 + 2 + 3 + 1 + 1,
+	}
+	if s := dec.Stats(); s != want {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+
+	if s := (&Decoder{}).Stats(); s != (DecoderStats{}) {
+		t.Errorf("got %+v, want zero", s)
+	}
+}
+
+func TestExtendedJSONNumbers(t *testing.T) {
+	type counts struct {
+		VertexHeader
+		Int   int64   `json:"int"`
+		Float float64 `json:"float"`
+		List  []int64 `json:"list"`
+	}
+
+	b := []byte(`v[3.1]{"int": {"$numberLong": "9007199254740993"}, "float": {"$numberDouble": "1.5"}, "list": [{"$numberInt": "1"}, 2]}`)
+	var c counts
+	if err := ScanEntity(b, &c); err == nil {
+		t.Error("error expected without ExtendedJSONNumbers")
+	}
+	err := ScanEntity(b, &c, ExtendedJSONNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Int != 9007199254740993 || c.Float != 1.5 || !reflect.DeepEqual(c.List, []int64{1, 2}) {
+		t.Errorf("got %+v, want {Int:9007199254740993 Float:1.5 List:[1 2]}", c)
+	}
+
+	var v BasicVertex
+	err = ScanEntity([]byte(`v[3.1]{"d": {"$numberDecimal": "0.1"}, "o": {"$numberLong": "1", "x": 2}, "s": "$number"}`), &v, ExtendedJSONNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"d": 0.1, "o": map[string]interface{}{"$numberLong": "1", "x": float64(2)}, "s": "$number"}
+	if !reflect.DeepEqual(v.Properties, want) {
+		t.Errorf("got %v, want %v", v.Properties, want)
+	}
+
+	err = ScanEntity([]byte(`v[3.1]{"n": {"$numberDouble": "-Infinity"}, "m": NaN}`), &v, ExtendedJSONNumbers(), NonFiniteNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := v.Properties["n"].(float64); !math.IsInf(n, -1) {
+		t.Errorf("got %v, want -Inf", v.Properties["n"])
+	}
+	if m, _ := v.Properties["m"].(float64); !math.IsNaN(m) {
+		t.Errorf("got %v, want NaN", v.Properties["m"])
+	}
+
+	for _, p := range []string{
+		`{"n": {"$numberLong": 1}}`,
+		`{"n": {"$numberLong": "1.5"}}`,
+		`{"n": {"$numberInt": "4294967296"}}`,
+		`{"n": {"$numberDecimal": "true"}}`,
+		`{"n": {"$numberDecimal": ""}}`,
+		`{"n": {"$numberDouble": "NaN"}}`,
+	} {
+		err := ScanEntity([]byte("v[3.1]"+p), &v, ExtendedJSONNumbers())
+		if err == nil {
+			t.Errorf("error expected for %s", p)
+		}
+	}
+}
+
+func TestSkipEndpoints(t *testing.T) {
+	dec := NewDecoder(SkipEndpoints())
+
+	var e BasicEdge
+	err := dec.ScanEntity([]byte(`e[4.1][3.1,3.2]{}`), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Label != "e" || e.Id.String() != "4.1" {
+		t.Errorf("got %s, want e[4.1]", e)
+	}
+	if e.Start.Valid || e.End.Valid {
+		t.Errorf("got %s and %s, want NULL", e.Start, e.End)
+	}
+
+	var es []BasicEdge
+	err = dec.ScanEntities([]byte(`[e[4.1][3.1,3.2]{},e[4.2][3.2,3.3]{}]`), &es)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 2 || es[1].Id.String() != "4.2" || es[1].Start.Valid {
+		t.Errorf("got %v, want 2 edges without endpoints", es)
+	}
+
+	err = dec.ScanEntity([]byte(`e[4.1][3.1,x]{}`), &e)
+	if err == nil {
+		t.Error("error expected for bad edge representation")
+	}
+}
+
+func BenchmarkScanEdges(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 1; i <= 1000; i++ {
+		if i > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "knows[4.%d][3.%d,3.%d]{}", i, i, i+1)
+	}
+	buf.WriteByte(']')
+	src := buf.Bytes()
+
+	for _, c := range []struct {
+		name string
+		dec  *Decoder
+	}{
+		{"Endpoints", NewDecoder(SkipProperties())},
+		{"SkipEndpoints", NewDecoder(SkipProperties(), SkipEndpoints())},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var es []BasicEdge
+				err := c.dec.ScanEntities(src, &es)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestInternLabels(t *testing.T) {
+	dec := NewDecoder(InternLabels())
+
+	var vs []BasicVertex
+	err := dec.ScanEntities([]byte(`[person[3.1]{},person[3.2]{},city[4.1]{}]`), &vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v BasicVertex
+	err = dec.ScanEntity([]byte(`person[3.3]{}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vs) != 3 || vs[0].Label != "person" || vs[2].Label != "city" {
+		t.Fatalf("got %v, want person, person, and city", vs)
+	}
+	if unsafe.StringData(vs[0].Label) != unsafe.StringData(vs[1].Label) || unsafe.StringData(vs[0].Label) != unsafe.StringData(v.Label) {
+		t.Error("labels are not interned")
+	}
+}
+
+func BenchmarkScanVerticesInternLabels(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 1; i <= 1000; i++ {
+		if i > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "person[3.%d]{}", i)
+	}
+	buf.WriteByte(']')
+	src := buf.Bytes()
+
+	for _, c := range []struct {
+		name string
+		dec  *Decoder
+	}{
+		{"Default", NewDecoder(SkipProperties())},
+		{"InternLabels", NewDecoder(SkipProperties(), InternLabels())},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var vs []BasicVertex
+				err := c.dec.ScanEntities(src, &vs)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestHooks(t *testing.T) {
+	var labels []string
+	var inputs []string
+	dec := NewDecoder(
+		OnEntity(func(label string) { labels = append(labels, label) }),
+		OnError(func(err error, input []byte) { inputs = append(inputs, string(input)) }),
+	)
+
+	var v BasicVertex
+	_ = dec.ScanEntity([]byte(`person[3.1]{}`), &v)
+	_ = dec.ScanEntity([]byte(`person[3.1]{`), &v)
+	_ = dec.ScanEntity(nil, &v)
+
+	var es []BasicEdge
+	_ = dec.ScanEntities([]byte(`[knows[4.1][3.1,3.2]{},NULL]`), &es)
+	_ = dec.ScanEntities([]byte(`[knows[4.1]]`), &es)
+
+	var p BasicPath
+	_ = dec.ScanPath([]byte(`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`), &p)
+	_ = dec.ScanPath([]byte(`[v[3.1]{},v[3.2]{}]`), &p)
+
+	wantLabels := []string{"person", "person", "knows", "v", "e", "v"}
+	if fmt.Sprint(labels) != fmt.Sprint(wantLabels) {
+		t.Errorf("got %q, want %q", labels, wantLabels)
+	}
+	wantInputs := []string{`person[3.1]{`, `[knows[4.1]]`, `[v[3.1]{},v[3.2]{}]`}
+	if fmt.Sprint(inputs) != fmt.Sprint(wantInputs) {
+		t.Errorf("got %q, want %q", inputs, wantInputs)
+	}
+
+	// hooks are off by default
+	err := ScanEntity([]byte(`person[3.1]{`), &v)
+	if err == nil {
+		t.Error("error expected for bad vertex")
+	}
+}
@@ -19,11 +19,27 @@ package ag_test
 import (
 	"database/sql"
 
+	"github.com/lib/pq"
 	"github.com/skaiworldwide-oss/agensgraph-golang"
 )
 
 var db *sql.DB
 
+func ExampleRegister() {
+	// Every connection opened by "agensgraph" has graph_path set to
+	// "mygraph".
+	ag.Register("agensgraph", &pq.Driver{}, "mygraph")
+
+	db, _ := sql.Open("agensgraph", "host=localhost dbname=postgres")
+	db.QueryRow(`MATCH (n:person) RETURN n LIMIT 1`)
+}
+
+func ExampleNewConnector() {
+	base, _ := pq.NewConnector("host=localhost dbname=postgres")
+	db := sql.OpenDB(ag.NewConnector(base, "mygraph"))
+	db.QueryRow(`MATCH (n:person) RETURN n LIMIT 1`)
+}
+
 func ExampleGraphId_Scan() {
 	var gid ag.GraphId
 	err := db.QueryRow(`MATCH (n) RETURN id(n) LIMIT 1`).Scan(&gid)
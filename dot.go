@@ -0,0 +1,78 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import "strings"
+
+// ToDOT returns a Graphviz DOT rendering of vertices and edges for debugging.
+// Each vertex is a node named by its ID and labeled with its label, and each
+// edge is an arc from its start vertex to its end vertex labeled with its
+// label. Edges may refer to vertices that are not in vertices. NULL vertices
+// and edges are skipped.
+func ToDOT(vertices []BasicVertex, edges []BasicEdge) string {
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+	for _, v := range vertices {
+		if !v.Valid {
+			continue
+		}
+		b.WriteString("\t")
+		writeDOTString(&b, v.Id.String())
+		b.WriteString(" [label=")
+		writeDOTString(&b, v.Label)
+		b.WriteString("];\n")
+	}
+	for _, e := range edges {
+		if !e.Valid {
+			continue
+		}
+		b.WriteString("\t")
+		writeDOTString(&b, e.Start.String())
+		b.WriteString(" -> ")
+		writeDOTString(&b, e.End.String())
+		b.WriteString(" [label=")
+		writeDOTString(&b, e.Label)
+		b.WriteString("];\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// PathToDOT returns a Graphviz DOT rendering of the vertices and the edges of
+// p. See ToDOT.
+func PathToDOT(p BasicPath) string {
+	return ToDOT(p.Vertices, p.Edges)
+}
+
+// writeDOTString writes s to b as a DOT quoted string.
+func writeDOTString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			// dropped; \n alone breaks a line in a label
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+}
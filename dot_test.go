@@ -0,0 +1,48 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import "testing"
+
+func TestPathToDOT(t *testing.T) {
+	var p BasicPath
+	err := p.Scan(`[person[3.1]{},knows[4.1][3.1,3.2]{},person[3.2]{},NULL,say"hi[5.1]{}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `digraph {
+	"3.1" [label="person"];
+	"3.2" [label="person"];
+	"5.1" [label="say\"hi"];
+	"3.1" -> "3.2" [label="knows"];
+}
+`
+	if s := PathToDOT(p); s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
+
+func TestToDOTEscape(t *testing.T) {
+	v := BasicVertex{Properties: nil}
+	v.Valid, v.Label, v.Id = true, "a\\b\nc", mustNewGraphId("3.1")
+
+	want := "digraph {\n\t\"3.1\" [label=\"a\\\\b\\nc\"];\n}\n"
+	if s := ToDOT([]BasicVertex{v}, nil); s != want {
+		t.Errorf("got %q, want %q", s, want)
+	}
+}
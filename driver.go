@@ -0,0 +1,113 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+)
+
+// Register makes a database driver available by the provided name. The driver
+// opens connections using base, and sets graph_path of each new connection to
+// graphPath so that queries need not set it.
+//
+// The data source name given to sql.Open is passed to base as is.
+//
+// If Register is called twice with the same name or base is nil, it panics.
+func Register(name string, base driver.Driver, graphPath string) {
+	if base == nil {
+		panic("ag: Register base driver is nil")
+	}
+	sql.Register(name, graphPathDriver{base, graphPath})
+}
+
+type graphPathDriver struct {
+	base      driver.Driver
+	graphPath string
+}
+
+func (d graphPathDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setGraphPath(context.Background(), conn, d.graphPath)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// NewConnector returns a driver.Connector that sets graph_path of each new
+// connection made by base to graphPath. It can be used with sql.OpenDB so that
+// every connection in the pool is configured.
+func NewConnector(base driver.Connector, graphPath string) driver.Connector {
+	return graphPathConnector{base, graphPath}
+}
+
+type graphPathConnector struct {
+	base      driver.Connector
+	graphPath string
+}
+
+func (c graphPathConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setGraphPath(ctx, conn, c.graphPath)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (c graphPathConnector) Driver() driver.Driver {
+	return graphPathDriver{c.base.Driver(), c.graphPath}
+}
+
+func setGraphPath(ctx context.Context, conn driver.Conn, graphPath string) error {
+	q := "SET graph_path = " + quoteIdentifier(graphPath)
+
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, q, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	stmt, err := conn.Prepare(q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+)
+
+type testDriver struct {
+	queries *[]string
+	fail    bool
+
+	// columns and rows are returned by Query
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (d testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{d}, nil
+}
+
+type testConn struct {
+	d testDriver
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{c, query}, nil
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type testStmt struct {
+	c     *testConn
+	query string
+}
+
+func (s *testStmt) Close() error {
+	return nil
+}
+
+func (s *testStmt) NumInput() int {
+	return -1
+}
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.c.d.fail {
+		return nil, errors.New("exec failed")
+	}
+	*s.c.d.queries = append(*s.c.d.queries, s.query)
+	return driver.RowsAffected(0), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.c.d.columns == nil {
+		return nil, errors.New("not supported")
+	}
+	return &testRows{s.c.d.columns, s.c.d.rows}, nil
+}
+
+type testRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *testRows) Columns() []string {
+	return r.columns
+}
+
+func (r *testRows) Close() error {
+	return nil
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.rows) < 1 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// openTestDB returns a database whose queries return columns and rows.
+func openTestDB(columns []string, rows ...[]driver.Value) *sql.DB {
+	var queries []string
+	return sql.OpenDB(testConnector{testDriver{queries: &queries, columns: columns, rows: rows}})
+}
+
+func TestRegister(t *testing.T) {
+	var queries []string
+	Register("ag_test_register", testDriver{queries: &queries}, `my"graph`)
+
+	db, err := sql.Open("ag_test_register", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec("MATCH (n) RETURN n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{`SET graph_path = "my""graph"`, "MATCH (n) RETURN n"}
+	if len(queries) != len(want) {
+		t.Fatalf("got %q, want %q", queries, want)
+	}
+	for i, q := range queries {
+		if q != want[i] {
+			t.Errorf("got %q, want %q", q, want[i])
+		}
+	}
+}
+
+func TestRegisterError(t *testing.T) {
+	var queries []string
+	Register("ag_test_register_error", testDriver{queries: &queries, fail: true}, "g")
+
+	db, err := sql.Open("ag_test_register_error", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = db.Ping()
+	if err == nil {
+		t.Error("error expected for failing SET graph_path")
+	}
+}
+
+type testConnector struct {
+	d testDriver
+}
+
+func (c testConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.d.Open("")
+}
+
+func (c testConnector) Driver() driver.Driver {
+	return c.d
+}
+
+func TestNewConnector(t *testing.T) {
+	var queries []string
+	db := sql.OpenDB(NewConnector(testConnector{testDriver{queries: &queries}}, "g"))
+	defer db.Close()
+	db.SetMaxIdleConns(0)
+
+	for i := 0; i < 2; i++ {
+		_, err := db.Exec("MATCH (n) RETURN n")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{`SET graph_path = "g"`, "MATCH (n) RETURN n", `SET graph_path = "g"`, "MATCH (n) RETURN n"}
+	if len(queries) != len(want) {
+		t.Fatalf("got %q, want %q", queries, want)
+	}
+	for i, q := range queries {
+		if q != want[i] {
+			t.Errorf("got %q, want %q", q, want[i])
+		}
+	}
+}
+
+func TestNewConnectorError(t *testing.T) {
+	var queries []string
+	db := sql.OpenDB(NewConnector(testConnector{testDriver{queries: &queries, fail: true}}, "g"))
+	defer db.Close()
+
+	err := db.Ping()
+	if err == nil {
+		t.Error("error expected for failing SET graph_path")
+	}
+}
@@ -17,12 +17,13 @@ limitations under the License.
 package ag
 
 import (
-	"bytes"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"strings"
 )
 
 // Edge gives any struct an ability to read the value from the database
@@ -37,27 +38,32 @@ type EdgeCore struct {
 	End   GraphId
 }
 
-var edgeCoreRegexp = regexp.MustCompile(`^(.+?)\[(\d+\.\d+)\]\[(\d+\.\d+),(\d+\.\d+)\]`)
+// Whitespace is allowed between the components and before the properties.
+var edgeCoreRegexp = regexp.MustCompile(`^(.+?)\s*\[\s*(\d+\.\d+)\s*\]\s*\[\s*(\d+\.\d+)\s*,\s*(\d+\.\d+)\s*\]\s*`)
 
-func (_ Edge) readEntity(b []byte) (*entityData, error) {
+func (_ Edge) readEntity(b []byte, o scanOptions) (*entityData, error) {
 	m := edgeCoreRegexp.FindSubmatch(b)
 	if m == nil {
 		return nil, fmt.Errorf("bad edge representation: %s", b)
 	}
 
-	return makeEdgeData(m[1], m[2], m[3], m[4], b[len(m[0]):])
+	return makeEdgeData(m[1], m[2], m[3], m[4], b[len(m[0]):], o)
 }
 
-func makeEdgeData(label, id, start, end, props []byte) (*entityData, error) {
+func makeEdgeData(label, id, start, end, props []byte, o scanOptions) (*entityData, error) {
 	var c EdgeCore
 
-	c.Label = string(label)
+	c.Label = o.label(label)
 
 	err := c.Id.Scan(id)
 	if err != nil {
 		return nil, errors.New("invalid edge ID: " + err.Error())
 	}
 
+	if o.skipEndpoints {
+		return &entityData{core: c, properties: props}, nil
+	}
+
 	err = c.Start.Scan(start)
 	if err != nil {
 		return nil, errors.New("invalid edge start ID: " + err.Error())
@@ -68,25 +74,34 @@ func makeEdgeData(label, id, start, end, props []byte) (*entityData, error) {
 		return nil, errors.New("invalid edge end ID: " + err.Error())
 	}
 
-	return &entityData{c, props}, nil
+	return &entityData{core: c, properties: props}, nil
 }
 
-func (_ Edge) readElements(b []byte) ([]interface{}, error) {
-	return readEdgeElements(b)
+func (_ Edge) readElement(b []byte, o scanOptions) (int, *entityData, error) {
+	return readEdgeElement(b, o)
 }
 
-func readEdgeElements(b []byte) ([]interface{}, error) {
-	// remove surrounding brackets
-	b = b[1 : len(b)-1]
+func (_ Edge) readElements(b []byte, o scanOptions) ([]interface{}, error) {
+	return readEdgeElements(b, o)
+}
+
+func readEdgeElements(b []byte, o scanOptions) ([]interface{}, error) {
+	b, err := trimBrackets(b, "edge")
+	if err != nil {
+		return nil, err
+	}
 
 	var ds []interface{}
 	for len(b) > 0 {
 		if len(ds) > 0 {
+			if b[0] != ',' {
+				return nil, fmt.Errorf("bad edge array representation: %s", b)
+			}
 			// remove comma
 			b = b[1:]
 		}
 
-		advance, data, err := readEdgeElement(b)
+		advance, data, err := readEdgeElement(b, o)
 		if err != nil {
 			return nil, err
 		}
@@ -102,8 +117,8 @@ func readEdgeElements(b []byte) ([]interface{}, error) {
 	return ds, nil
 }
 
-func readEdgeElement(b []byte) (advance int, data *entityData, err error) {
-	if bytes.HasPrefix(b, nullElementValue) {
+func readEdgeElement(b []byte, o scanOptions) (advance int, data *entityData, err error) {
+	if hasNullPrefix(b) {
 		advance = len(nullElementValue)
 		return
 	}
@@ -122,7 +137,7 @@ func readEdgeElement(b []byte) (advance int, data *entityData, err error) {
 	}
 	advance += len(props)
 
-	data, err = makeEdgeData(m[1], m[2], m[3], m[4], props)
+	data, err = makeEdgeData(m[1], m[2], m[3], m[4], props, o)
 	return
 }
 
@@ -156,18 +171,45 @@ func (h *EdgeHeader) SaveEntity(valid bool, core interface{}) error {
 // This is a reference implementation of an entity for edge using all the basic
 // building blocks(Edge, EdgeCore, EdgeHeader, EntitySaver, PropertiesSaver,
 // and ScanEntity.)
+//
+// BasicEdge implements encoding.TextMarshaler and json.Marshaler. A struct that
+// embeds BasicEdge gets these methods too, so encoding/json encodes it as the
+// edge alone and drops its other fields. To keep them, hold BasicEdge in a
+// named field, or embed EdgeHeader and declare Properties instead.
 type BasicEdge struct {
 	EdgeHeader
 	Properties map[string]interface{}
 }
 
 func (e BasicEdge) String() string {
-	if e.Valid {
-		p, _ := json.Marshal(e.Properties)
-		return fmt.Sprintf("%s[%s][%s,%s]%s", e.Label, e.Id, e.Start, e.End, p)
-	} else {
-		return "NULL"
+	var b strings.Builder
+	e.writeString(&b)
+	return b.String()
+}
+
+// writeString writes the text form of e, which String returns, to b. If the
+// properties cannot be marshaled, it writes the text form without them and
+// returns the error.
+func (e BasicEdge) writeString(b *strings.Builder) error {
+	if !e.Valid {
+		b.Write(nullElementValue)
+		return nil
 	}
+
+	p, err := MarshalProperties(e.Properties)
+	b.WriteString(e.Label)
+	b.WriteByte('[')
+	b.WriteString(e.Id.String())
+	b.WriteString("][")
+	b.WriteString(e.Start.String())
+	b.WriteByte(',')
+	b.WriteString(e.End.String())
+	b.WriteByte(']')
+	b.Write(p)
+	if err != nil {
+		return errors.New("invalid edge properties: " + err.Error())
+	}
+	return nil
 }
 
 // SaveProperties implements PropertiesSaver interface. It calls json.Unmarshal
@@ -180,11 +222,264 @@ func (e *BasicEdge) SaveProperties(b []byte) error {
 	return nil
 }
 
+func (e *BasicEdge) setProperties(m map[string]interface{}) {
+	e.Properties = m
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. It returns the
+// same text form as String does.
+func (e BasicEdge) MarshalText() ([]byte, error) {
+	var b strings.Builder
+	err := e.writeString(&b)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// CopyLine returns e as a line, without the newline, in the text format of
+// COPY for the table of the label of e, whose columns are id, start, end, and
+// properties:
+//
+//	COPY graph.knows (id, start, "end", properties) FROM STDIN
+//
+// See (BasicVertex).CopyLine for the escaping. CopyLine returns "" if e is
+// NULL, it has no start or end, for example when scanned with SkipEndpoints,
+// or its properties cannot be marshaled. The row must be skipped then. Use
+// AppendCopyLine to tell them apart.
+func (e BasicEdge) CopyLine() string {
+	b, err := e.AppendCopyLine(nil)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// AppendCopyLine appends the line that CopyLine returns to buf and returns the
+// extended buffer. It returns buf unchanged if e is NULL, and an error if e
+// has no start or end or its properties cannot be marshaled.
+func (e BasicEdge) AppendCopyLine(buf []byte) ([]byte, error) {
+	if !e.Valid {
+		return buf, nil
+	}
+	if !e.Start.Valid || !e.End.Valid {
+		return buf, errors.New("edge without start or end")
+	}
+
+	p, err := marshalCopyProperties(e.Properties)
+	if err != nil {
+		return buf, errors.New("invalid edge properties: " + err.Error())
+	}
+	buf = e.Id.AppendTo(buf)
+	buf = append(buf, '\t')
+	buf = e.Start.AppendTo(buf)
+	buf = append(buf, '\t')
+	buf = e.End.AppendTo(buf)
+	buf = append(buf, '\t')
+	return append(buf, p...), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. It reads
+// the text form returned by MarshalText, including "NULL".
+func (e *BasicEdge) UnmarshalText(b []byte) error {
+	if isNullElement(b) {
+		return ScanEntity(nil, e)
+	}
+	return ScanEntity(b, e)
+}
+
+// basicEdgeFields is BasicEdge without its methods, so that encoding/json
+// encodes and decodes its fields.
+type basicEdgeFields BasicEdge
+
+// MarshalJSON implements the json.Marshaler interface. It encodes e as a JSON
+// object of its fields rather than the text form of MarshalText, which
+// encoding/json would use otherwise.
+func (e BasicEdge) MarshalJSON() ([]byte, error) {
+	return json.Marshal(basicEdgeFields(e))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It reads either the
+// JSON object returned by MarshalJSON or a JSON string of the text form.
+func (e *BasicEdge) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		err := json.Unmarshal(b, &s)
+		if err != nil {
+			return err
+		}
+		return e.UnmarshalText([]byte(s))
+	}
+	return json.Unmarshal(b, (*basicEdgeFields)(e))
+}
+
 // Scan implements the database/sql Scanner interface. It calls ScanEntity.
 func (e *BasicEdge) Scan(src interface{}) error {
 	return ScanEntity(src, e)
 }
 
+// Equal reports whether e and x have the same label, IDs, and properties. Two
+// NULL edges are equal. Properties are compared as PropertiesEqualIgnoring
+// does, so nil properties equal empty properties.
+func (e BasicEdge) Equal(x BasicEdge) bool {
+	if !e.Valid || !x.Valid {
+		return e.Valid == x.Valid
+	}
+	return e.EdgeCore == x.EdgeCore && PropertiesEqualIgnoring(e.Properties, x.Properties)
+}
+
+// AsTriple returns the start vertex ID, the label, and the end vertex ID of e
+// as a subject-predicate-object triple.
+func (e BasicEdge) AsTriple() (subject GraphId, predicate string, object GraphId) {
+	return e.Start, e.Label, e.End
+}
+
+// Connects reports whether e connects the vertices whose IDs are a and b in
+// either direction.
+func (e BasicEdge) Connects(a, b GraphId) bool {
+	return e.ConnectsDirected(a, b) || e.ConnectsDirected(b, a)
+}
+
+// ConnectsDirected reports whether e starts at the vertex whose ID is from and
+// ends at the vertex whose ID is to. For a self-loop, from and to are the
+// same.
+func (e BasicEdge) ConnectsDirected(from, to GraphId) bool {
+	return e.Valid && e.Start.Equal(from) && e.End.Equal(to)
+}
+
+// Resolve returns the start and end vertices of e from vertices, which maps
+// vertex IDs to vertices. ok is false if either of them is not in vertices.
+func (e BasicEdge) Resolve(vertices map[GraphId]BasicVertex) (start, end BasicVertex, ok bool) {
+	start, ok = vertices[e.Start]
+	if !ok {
+		return BasicVertex{}, BasicVertex{}, false
+	}
+	end, ok = vertices[e.End]
+	if !ok {
+		return BasicVertex{}, BasicVertex{}, false
+	}
+	return start, end, true
+}
+
+// Triple is a subject-predicate-object representation of an edge.
+type Triple struct {
+	Subject   GraphId // start vertex ID
+	Predicate string  // label
+	Object    GraphId // end vertex ID
+}
+
+// GroupEdgesByStart groups edges by their start vertex IDs. Edges in each
+// group are in the same order as in edges. Invalid edges are skipped.
+func GroupEdgesByStart(edges []BasicEdge) map[GraphId][]BasicEdge {
+	return groupEdges(edges, func(e *BasicEdge) GraphId { return e.Start })
+}
+
+// GroupEdgesByEnd groups edges by their end vertex IDs. Edges in each group
+// are in the same order as in edges. Invalid edges are skipped.
+func GroupEdgesByEnd(edges []BasicEdge) map[GraphId][]BasicEdge {
+	return groupEdges(edges, func(e *BasicEdge) GraphId { return e.End })
+}
+
+func groupEdges(edges []BasicEdge, key func(e *BasicEdge) GraphId) map[GraphId][]BasicEdge {
+	g := make(map[GraphId][]BasicEdge)
+	for i := range edges {
+		e := &edges[i]
+		if !e.Valid {
+			continue
+		}
+		k := key(e)
+		g[k] = append(g[k], *e)
+	}
+	return g
+}
+
+// Degrees returns the incoming and outgoing degrees of every vertex that is
+// the start or the end vertex of edges. Invalid edges are skipped.
+//
+// A self-loop, whose start and end vertices are the same, counts in both In
+// and Out of the vertex.
+func Degrees(edges []BasicEdge) map[GraphId]struct{ In, Out int } {
+	ds := make(map[GraphId]struct{ In, Out int })
+	for i := range edges {
+		e := &edges[i]
+		if !e.Valid {
+			continue
+		}
+
+		d := ds[e.Start]
+		d.Out++
+		ds[e.Start] = d
+
+		d = ds[e.End]
+		d.In++
+		ds[e.End] = d
+	}
+	return ds
+}
+
+// AdjacencyMatrix returns the weighted adjacency matrix of edges, in which the
+// element at row i and column j is the weight of the edge from vertices[i] to
+// vertices[j]. weight returns the weight of an edge; if weight is nil, every
+// edge weighs 1. Missing edges are 0 and the weights of parallel edges are
+// summed, so that the matrix counts the edges if weight is nil.
+//
+// Invalid edges and edges whose start or end vertex is not in vertices are
+// skipped.
+func AdjacencyMatrix(vertices []GraphId, edges []BasicEdge, weight func(BasicEdge) float64) [][]float64 {
+	return adjacencyMatrix(vertices, edges, weight, 0, func(a, b float64) float64 { return a + b })
+}
+
+// DistanceMatrix is like AdjacencyMatrix, but missing edges are +Inf and the
+// minimum weight of parallel edges is used, which suits shortest path
+// algorithms. The diagonal is +Inf unless there is a self-loop; set it to 0 if
+// the algorithm requires.
+func DistanceMatrix(vertices []GraphId, edges []BasicEdge, weight func(BasicEdge) float64) [][]float64 {
+	return adjacencyMatrix(vertices, edges, weight, math.Inf(1), math.Min)
+}
+
+func adjacencyMatrix(vertices []GraphId, edges []BasicEdge, weight func(BasicEdge) float64, missing float64, merge func(a, b float64) float64) [][]float64 {
+	idx := make(map[GraphId]int, len(vertices))
+	for i, id := range vertices {
+		idx[id] = i
+	}
+
+	n := len(vertices)
+	m := make([][]float64, n)
+	// one backing array for all the rows
+	elems := make([]float64, n*n)
+	for i := range elems {
+		elems[i] = missing
+	}
+	for i := range m {
+		m[i] = elems[i*n : (i+1)*n : (i+1)*n]
+	}
+
+	set := make([]bool, n*n)
+	for _, e := range edges {
+		if !e.Valid {
+			continue
+		}
+		i, ok := idx[e.Start]
+		if !ok {
+			continue
+		}
+		j, ok := idx[e.End]
+		if !ok {
+			continue
+		}
+
+		w := float64(1)
+		if weight != nil {
+			w = weight(e)
+		}
+		if set[i*n+j] {
+			w = merge(m[i][j], w)
+		}
+		m[i][j], set[i*n+j] = w, true
+	}
+	return m
+}
+
 type basicEdgeArray []BasicEdge
 
 func (a *basicEdgeArray) Scan(src interface{}) error {
@@ -201,7 +496,7 @@ func (a *basicEdgeArray) Scan(src interface{}) error {
 		return fmt.Errorf("invalid source for _edge: %v", b)
 	}
 
-	ds, err := readEdgeElements(b)
+	ds, err := readEdgeElements(b, scanOptions{})
 	if err != nil {
 		return errors.New("failed to read edge elements: " + err.Error())
 	}
@@ -16,7 +16,12 @@ limitations under the License.
 
 package ag
 
-import "testing"
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
 
 // (Edge).readEntity, makeEdgeData
 func TestBasicEdgeScanError(t *testing.T) {
@@ -51,6 +56,249 @@ func TestBasicEdgeScan(t *testing.T) {
 	}
 }
 
+func TestBasicEdgeScanWhitespace(t *testing.T) {
+	tests := []string{
+		`knows[4.1][3.1,3.2]{"a": 1}`,
+		`knows[4.1][3.1,3.2] {"a": 1}`,
+		`knows [ 4.1 ] [ 3.1 , 3.2 ] {"a": 1}`,
+	}
+	for _, b := range tests {
+		var e BasicEdge
+		err := e.Scan(b)
+		if err != nil {
+			t.Error(err)
+		} else if s, want := e.String(), `knows[4.1][3.1,3.2]{"a":1}`; s != want {
+			t.Errorf("got %s, want %s", s, want)
+		}
+
+		var es []BasicEdge
+		err = Array(&es).Scan([]byte("[" + b + "," + b + "]"))
+		if err != nil {
+			t.Error(err)
+		} else if len(es) != 2 || es[1].Label != "knows" {
+			t.Errorf("got %v, want 2 edges", es)
+		}
+	}
+}
+
+func TestBasicEdgeScanString(t *testing.T) {
+	src := `e[4.1][3.1,3.2]{"name": "go"}`
+	var e BasicEdge
+	err := e.Scan(src)
+	if err != nil {
+		t.Error(err)
+	} else if !e.Valid {
+		t.Errorf("got NULL, want Valid %T", e)
+	} else if !e.Start.Equal(mustNewGraphId("3.1")) {
+		t.Errorf("got %s, want 3.1", e.Start)
+	}
+}
+
+func TestBasicEdgeScanTruncated(t *testing.T) {
+	const s = `knows[4.1][3.1,3.2]{"since": "2020}", "w": [1, {"a": 2}]}`
+	checkTruncated(t, s, func(b []byte) error {
+		var e BasicEdge
+		return e.Scan(b)
+	})
+	checkTruncated(t, s, func(b []byte) error {
+		var e BasicEdge
+		_, err := ScanEntityN(b, &e)
+		return err
+	})
+	checkTruncated(t, "["+s+",NULL,"+s+"]", func(b []byte) error {
+		var es []BasicEdge
+		return Array(&es).Scan(b)
+	})
+	checkTruncated(t, "["+s+",NULL,"+s+"]", func(b []byte) error {
+		var es []BasicEdge
+		return ScanEntities(b, &es)
+	})
+}
+
+func TestBasicEdgeText(t *testing.T) {
+	tests := []string{
+		`e[4.1][3.1,3.2]{"since":2009}`,
+		"NULL",
+	}
+	for _, c := range tests {
+		var e BasicEdge
+		err := e.UnmarshalText([]byte(c))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		b, err := e.MarshalText()
+		if err != nil {
+			t.Error(err)
+		} else if string(b) != c {
+			t.Errorf("got %s, want %s", b, c)
+		}
+	}
+}
+
+func TestBasicEdgeJSON(t *testing.T) {
+	var e BasicEdge
+	err := e.Scan(`knows[4.1][3.1,3.2]{"since":2009}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) < 1 || b[0] != '{' {
+		t.Errorf("got %s, want a JSON object", b)
+	}
+
+	var x BasicEdge
+	err = json.Unmarshal(b, &x)
+	if err != nil {
+		t.Error(err)
+	} else if !x.Equal(e) {
+		t.Errorf("got %s, want %s", x, e)
+	}
+
+	err = json.Unmarshal([]byte(`"knows[4.1][3.1,3.2]{\"since\":2009}"`), &x)
+	if err != nil {
+		t.Error(err)
+	} else if !x.Equal(e) {
+		t.Errorf("got %s, want %s", x, e)
+	}
+}
+
+func TestGroupEdges(t *testing.T) {
+	var es []BasicEdge
+	err := Array(&es).Scan([]byte(`[e[4.1][3.1,3.2]{},NULL,e[4.2][3.2,3.1]{},e[4.3][3.1,3.3]{}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		groups map[GraphId][]BasicEdge
+		key    string
+		ids    []string
+	}{
+		{GroupEdgesByStart(es), "3.1", []string{"4.1", "4.3"}},
+		{GroupEdgesByStart(es), "3.2", []string{"4.2"}},
+		{GroupEdgesByEnd(es), "3.1", []string{"4.2"}},
+		{GroupEdgesByEnd(es), "3.3", []string{"4.3"}},
+	}
+	for _, c := range tests {
+		g := c.groups[mustNewGraphId(c.key)]
+		if len(g) != len(c.ids) {
+			t.Errorf("got %d edges for %s, want %d", len(g), c.key, len(c.ids))
+			continue
+		}
+		for i, e := range g {
+			if id := e.Id.String(); id != c.ids[i] {
+				t.Errorf("got %s, want %s", id, c.ids[i])
+			}
+		}
+	}
+
+	if n := len(GroupEdgesByStart(es)); n != 2 {
+		t.Errorf("got %d groups, want 2", n)
+	}
+}
+
+func TestBasicEdgeConnects(t *testing.T) {
+	var e, loop BasicEdge
+	if err := e.Scan([]byte(`e[4.1][3.1,3.2]{}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := loop.Scan([]byte(`e[4.2][3.3,3.3]{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		e        BasicEdge
+		a        string
+		b        string
+		connects bool
+		directed bool
+	}{
+		{e, "3.1", "3.2", true, true},
+		{e, "3.2", "3.1", true, false},
+		{e, "3.1", "3.3", false, false},
+		{e, "3.1", "3.1", false, false},
+		{loop, "3.3", "3.3", true, true},
+		{loop, "3.3", "3.1", false, false},
+		{BasicEdge{}, "NULL", "NULL", false, false},
+	}
+	for _, c := range tests {
+		a, b := mustNewGraphId(c.a), mustNewGraphId(c.b)
+		if got := c.e.Connects(a, b); got != c.connects {
+			t.Errorf("got %s.Connects(%s, %s) == %t, want %t", c.e, a, b, got, c.connects)
+		}
+		if got := c.e.ConnectsDirected(a, b); got != c.directed {
+			t.Errorf("got %s.ConnectsDirected(%s, %s) == %t, want %t", c.e, a, b, got, c.directed)
+		}
+	}
+}
+
+func TestBasicEdgeResolve(t *testing.T) {
+	var vs []BasicVertex
+	err := Array(&vs).Scan([]byte(`[v[3.1]{},v[3.2]{}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := make(map[GraphId]BasicVertex)
+	for _, v := range vs {
+		m[v.Id] = v
+	}
+
+	var e BasicEdge
+	err = e.Scan([]byte(`e[4.1][3.1,3.2]{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end, ok := e.Resolve(m)
+	if !ok {
+		t.Error("got false, want true")
+	} else if start.Id.String() != "3.1" || end.Id.String() != "3.2" {
+		t.Errorf("got %s and %s, want 3.1 and 3.2", start.Id, end.Id)
+	}
+
+	err = e.Scan([]byte(`e[4.2][3.1,3.3]{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end, ok = e.Resolve(m)
+	if ok || start.Valid || end.Valid {
+		t.Errorf("got %s, %s, %t, want NULL, NULL, false", start, end, ok)
+	}
+}
+
+func TestDegrees(t *testing.T) {
+	var es []BasicEdge
+	err := Array(&es).Scan([]byte(`[e[4.1][3.1,3.2]{},NULL,e[4.2][3.2,3.1]{},e[4.3][3.1,3.3]{},e[4.4][3.3,3.3]{}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key string
+		in  int
+		out int
+	}{
+		{"3.1", 1, 2},
+		{"3.2", 1, 1},
+		{"3.3", 2, 1},
+	}
+	ds := Degrees(es)
+	if len(ds) != len(tests) {
+		t.Errorf("got %d vertices, want %d", len(ds), len(tests))
+	}
+	for _, c := range tests {
+		d := ds[mustNewGraphId(c.key)]
+		if d.In != c.in || d.Out != c.out {
+			t.Errorf("got %+v for %s, want {In:%d Out:%d}", d, c.key, c.in, c.out)
+		}
+	}
+}
+
 func TestBasicEdgeArrayScanNil(t *testing.T) {
 	var es []BasicEdge
 	err := Array(&es).Scan(nil)
@@ -159,3 +407,66 @@ func TestServerEdge(t *testing.T) {
 		t.Errorf("got %v, want NULL", es[0])
 	}
 }
+
+func TestAdjacencyMatrix(t *testing.T) {
+	var es []BasicEdge
+	err := Array(&es).Scan([]byte(`[e[4.1][3.1,3.2]{"w": 2},e[4.2][3.1,3.2]{"w": 3},NULL,e[4.3][3.2,3.3]{"w": 1},e[4.4][3.3,3.9]{"w": 1}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := []GraphId{mustNewGraphId("3.1"), mustNewGraphId("3.2"), mustNewGraphId("3.3")}
+	weight := func(e BasicEdge) float64 { return e.Properties["w"].(float64) }
+	inf := math.Inf(1)
+
+	tests := []struct {
+		m    [][]float64
+		want [][]float64
+	}{
+		{AdjacencyMatrix(vs, es, weight), [][]float64{{0, 5, 0}, {0, 0, 1}, {0, 0, 0}}},
+		{AdjacencyMatrix(vs, es, nil), [][]float64{{0, 2, 0}, {0, 0, 1}, {0, 0, 0}}},
+		{DistanceMatrix(vs, es, weight), [][]float64{{inf, 2, inf}, {inf, inf, 1}, {inf, inf, inf}}},
+	}
+	for _, c := range tests {
+		if !reflect.DeepEqual(c.m, c.want) {
+			t.Errorf("got %v, want %v", c.m, c.want)
+		}
+	}
+
+	if m := AdjacencyMatrix(nil, es, nil); len(m) != 0 {
+		t.Errorf("got %v, want empty", m)
+	}
+}
+
+func TestBasicEdgeCopyLine(t *testing.T) {
+	var e BasicEdge
+	err := e.Scan([]byte(`knows[4.1][3.1,3.2]{"note": "a\\b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "4.1\t3.1\t3.2\t" + `{"note":"a\\\\b"}`
+	if s := e.CopyLine(); s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	if s := (BasicEdge{}).CopyLine(); s != "" {
+		t.Errorf("got %q for NULL, want empty", s)
+	}
+
+	b, err := e.AppendCopyLine([]byte("x"))
+	if err != nil {
+		t.Error(err)
+	} else if string(b) != "x"+want {
+		t.Errorf("got %s, want x%s", b, want)
+	}
+
+	err = ScanEntity([]byte(`knows[4.1][3.1,3.2]{}`), &e, SkipEndpoints())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := e.CopyLine(); s != "" {
+		t.Errorf("got %q without endpoints, want empty", s)
+	}
+	if _, err := e.AppendCopyLine(nil); err == nil {
+		t.Error("error expected for an edge without endpoints")
+	}
+}
@@ -17,8 +17,17 @@ limitations under the License.
 package ag
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // Entity is an interface used by ScanEntity. Any struct that has Vertex or
@@ -30,12 +39,176 @@ type Entity interface {
 }
 
 type entityReader interface {
-	readEntity(b []byte) (*entityData, error)
+	readEntity(b []byte, o scanOptions) (*entityData, error)
+	readElement(b []byte, o scanOptions) (advance int, data *entityData, err error)
 }
 
 type entityData struct {
 	core       interface{}
 	properties []byte
+	// skipProperties is set for the elements of a path to carry the options
+	// of ScanPath over to SavePath.
+	skipProperties bool
+}
+
+func (d *entityData) label() string {
+	switch c := d.core.(type) {
+	case VertexCore:
+		return c.Label
+	case EdgeCore:
+		return c.Label
+	default:
+		return ""
+	}
+}
+
+// String returns the label and the ID of the entity such as "person[3.1]".
+func (d *entityData) String() string {
+	switch c := d.core.(type) {
+	case VertexCore:
+		return fmt.Sprintf("%s[%s]", c.Label, c.Id)
+	case EdgeCore:
+		return fmt.Sprintf("%s[%s]", c.Label, c.Id)
+	default:
+		return fmt.Sprintf("%v", c)
+	}
+}
+
+// SplitLabel splits a label read by ScanEntity into a graph name and a label
+// name.
+//
+// ScanEntity stores a label as it is in the text form. On a database with
+// multiple graphs, a label may be qualified by its graph name (e.g.
+// "social.person"), and Label of VertexCore and EdgeCore keeps the prefix. A
+// label is graph-qualified if it has a dot outside double quotes; it is split
+// at the first such dot, and double-quoted parts are unquoted. If label is
+// not graph-qualified, graph is empty and name is the unquoted label.
+func SplitLabel(label string) (graph, name string) {
+	quoted := false
+	for i := 0; i < len(label); i++ {
+		switch label[i] {
+		case '"':
+			quoted = !quoted
+		case '.':
+			if !quoted {
+				return unquoteIdentifier(label[:i]), unquoteIdentifier(label[i+1:])
+			}
+		}
+	}
+	return "", unquoteIdentifier(label)
+}
+
+func unquoteIdentifier(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+}
+
+// PeekLabel returns the label of a vertex or an edge in b without parsing the
+// rest of b. The label is everything before the first '['. If the label is
+// double-quoted, it may contain '[' and doubled double quotes, and the
+// unquoted label is returned.
+//
+// An error will be returned if b does not begin with a label followed by '[',
+// as for a graphpath or a graphid.
+func PeekLabel(b []byte) (string, error) {
+	if len(b) > 0 && b[0] == '"' {
+		var label []byte
+		for i := 1; i < len(b); i++ {
+			if b[i] != '"' {
+				label = append(label, b[i])
+				continue
+			}
+			if i+1 < len(b) && b[i+1] == '"' {
+				label = append(label, '"')
+				i++
+				continue
+			}
+			if i+1 < len(b) && b[i+1] == '[' && len(label) > 0 {
+				return string(label), nil
+			}
+			break
+		}
+		return "", fmt.Errorf("bad label representation: %s", b)
+	}
+
+	i := bytes.IndexByte(b, '[')
+	if i < 1 {
+		return "", fmt.Errorf("bad label representation: %s", b)
+	}
+	return string(b[:i]), nil
+}
+
+// DistinctLabels returns the sorted distinct labels of the vertices and edges
+// in elements. Each element is the text of a vertex, an edge, a graphpath, or
+// an array of vertices and edges, such as a column of rows. NULL elements,
+// whether nil or "NULL", are skipped. Properties are not decoded.
+//
+// An error will be returned if any element is none of the above.
+func DistinctLabels(elements [][]byte) ([]string, error) {
+	seen := make(map[string]struct{})
+	for _, b := range elements {
+		if b == nil || isNullElement(b) {
+			continue
+		}
+
+		if t := bytes.TrimLeft(b, " \t\r\n"); len(t) > 0 && t[0] == '[' {
+			labels, err := elementLabels(b)
+			if err != nil {
+				return nil, err
+			}
+			for _, l := range labels {
+				seen[l] = struct{}{}
+			}
+			continue
+		}
+
+		l, err := PeekLabel(b)
+		if err != nil {
+			return nil, err
+		}
+		seen[l] = struct{}{}
+	}
+
+	labels := make([]string, 0, len(seen))
+	for l := range seen {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// elementLabels returns the labels of the elements of the path or the array b.
+func elementLabels(b []byte) ([]string, error) {
+	var labels []string
+	if isJSONPath(b) {
+		ds, err := readJSONPath(b, scanOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range ds {
+			if d != nil {
+				labels = append(labels, d.(*entityData).label())
+			}
+		}
+		return labels, nil
+	}
+
+	// A graphpath has the same text form as an array of vertices and edges.
+	es, err := ScanMixedArray(b, SkipProperties())
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range es {
+		switch e := e.(type) {
+		case BasicVertex:
+			labels = append(labels, e.Label)
+		case BasicEdge:
+			labels = append(labels, e.Label)
+		}
+	}
+	return labels, nil
 }
 
 // EntitySaver is an interface used by ScanEntity.
@@ -58,52 +231,924 @@ type PropertiesSaver interface {
 	// the entity itself by calling json.Unmarshal over it. To modify this
 	// default behavior, one may implement PropertiesSaver for the entity.
 	//
-	// The underlying array of b may be reused.
+	// The underlying array of b may be reused, so b must not be retained
+	// after SaveProperties returns. Use CopyProperties to keep b, or scan
+	// with the CopyPropertiesOnSave option.
 	//
 	// An error should be returned if the properties cannot be stored
 	// without loss of information.
 	SaveProperties(b []byte) error
 }
 
+// PropertiesMerger is an interface used by ScanEntity.
+type PropertiesMerger interface {
+	// If an entity implements PropertiesMerger, ScanEntity calls
+	// MergeProperties instead of SaveProperties or json.Unmarshal so that
+	// the properties of the same entity read from multiple rows can be
+	// accumulated. MergeDeep may be used to implement it.
+	//
+	// Since ScanEntity is called once for each row, properties are merged
+	// in the order of the rows. SaveEntity is still called for every row
+	// before MergeProperties.
+	//
+	// The underlying array of b may be reused as for SaveProperties.
+	MergeProperties(b []byte) error
+}
+
+// saveTextProperties passes b to entity if it implements PropertiesTextSaver,
+// which is only available when built with GOEXPERIMENT=jsonv2. saved is false
+// if entity does not take the properties this way.
+var saveTextProperties = func(entity Entity, b []byte) (saved bool, err error) {
+	return false, nil
+}
+
+// PropertiesRequirer is an interface used by ScanEntity.
+type PropertiesRequirer interface {
+	// RequiredProperties returns the keys of properties that an entity
+	// must have. If an entity implements PropertiesRequirer, ScanEntity
+	// returns an error listing all the missing keys after the properties
+	// are stored.
+	RequiredProperties() []string
+}
+
+// ReservedPropertiesSaver is an interface used by ScanEntity.
+type ReservedPropertiesSaver interface {
+	// SaveReservedProperties assigns the reserved properties of an entity,
+	// which are the properties whose keys begin with an underscore (e.g.
+	// "__id__"). If an entity implements ReservedPropertiesSaver, ScanEntity
+	// calls it with the decoded values of those properties after the
+	// properties are stored. m is empty if there are none.
+	//
+	// AgensGraph does not reserve any property key by itself; the
+	// underscore prefix is the convention used by the configurations and
+	// tools that put server-provided metadata in properties.
+	SaveReservedProperties(m map[string]interface{}) error
+}
+
+// ReservedProperties may be used as an embedded field of an entity to keep its
+// reserved properties. See ReservedPropertiesSaver.
+type ReservedProperties struct {
+	Reserved map[string]interface{} `json:"-"`
+}
+
+// SaveReservedProperties implements ReservedPropertiesSaver interface.
+func (r *ReservedProperties) SaveReservedProperties(m map[string]interface{}) error {
+	r.Reserved = m
+	return nil
+}
+
+// UnknownPropertiesSaver is an interface used by ScanEntity.
+type UnknownPropertiesSaver interface {
+	// SaveUnknown is called with the sorted keys of the properties that do
+	// not match any field of an entity, which encoding/json ignores, after
+	// ScanEntity unmarshals the properties into the fields. keys is nil if
+	// there are none. Keys match fields case-insensitively as in
+	// encoding/json.
+	//
+	// It is not called for entities that store the properties by themselves
+	// such as PropertiesSaver, since they know which keys they use.
+	SaveUnknown(keys []string)
+}
+
+// UnknownProperties may be used as an embedded field of an entity to keep the
+// keys of its unknown properties. See UnknownPropertiesSaver.
+type UnknownProperties struct {
+	Unknown []string `json:"-"`
+}
+
+// SaveUnknown implements UnknownPropertiesSaver interface.
+func (u *UnknownProperties) SaveUnknown(keys []string) {
+	u.Unknown = keys
+}
+
+// ScanOption changes the default behavior of ScanEntity.
+type ScanOption func(o *scanOptions)
+
+type scanOptions struct {
+	strict         bool
+	skipProperties bool
+	copyProperties bool
+	useNumber      bool
+	skipEndpoints  bool
+	labelRegexp    *regexp.Regexp
+	labels         *labelTable
+	onError        func(err error, input []byte)
+	onEntity       func(label string)
+	lenient        bool
+	nonFinite      bool
+	lowercaseKeys  bool
+	extendedJSON   bool
+	tracer         Tracer
+	registry       *PropertiesRegistry
+	skipVertexProp bool
+	skipEdgeProp   bool
+	stats          *decoderStats
+}
+
+func newScanOptions(opts []ScanOption) scanOptions {
+	var o scanOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Strict enables strict mode. In strict mode, ScanEntity returns an error if
+// the properties of an entity contain duplicate keys at any depth instead of
+// letting the last one win.
+func Strict() ScanOption {
+	return func(o *scanOptions) {
+		o.strict = true
+	}
+}
+
+// SkipProperties makes ScanEntity call only SaveEntity of an entity. The
+// properties are neither decoded nor stored, and they are not checked by
+// Strict and PropertiesRequirer. It is useful when only the label and the IDs
+// of entities are needed.
+func SkipProperties() ScanOption {
+	return func(o *scanOptions) {
+		o.skipProperties = true
+	}
+}
+
+// SkipVertexProperties is like SkipProperties, but only for vertices. It is
+// useful for ScanPath and ScanMixedArray when only the properties of edges are
+// needed. The vertices in a BasicPath have nil Properties.
+func SkipVertexProperties() ScanOption {
+	return func(o *scanOptions) {
+		o.skipVertexProp = true
+	}
+}
+
+// SkipEdgeProperties is like SkipProperties, but only for edges. It is useful
+// for ScanPath and ScanMixedArray when only the properties of vertices are
+// needed. The edges in a BasicPath have nil Properties.
+func SkipEdgeProperties() ScanOption {
+	return func(o *scanOptions) {
+		o.skipEdgeProp = true
+	}
+}
+
+// skipsProperties reports whether the options skip the properties of d.
+func (o scanOptions) skipsProperties(d *entityData) bool {
+	if o.skipProperties {
+		return true
+	}
+	switch d.core.(type) {
+	case VertexCore:
+		return o.skipVertexProp
+	case EdgeCore:
+		return o.skipEdgeProp
+	}
+	return false
+}
+
+// CopyPropertiesOnSave makes ScanEntity pass a fresh copy of the properties to
+// SaveProperties so that PropertiesSaver can retain it without copying.
+func CopyPropertiesOnSave() ScanOption {
+	return func(o *scanOptions) {
+		o.copyProperties = true
+	}
+}
+
+// CopyProperties returns a copy of b that does not share the underlying array
+// with b. PropertiesSaver should use it to retain the properties given to
+// SaveProperties.
+func CopyProperties(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+// LenientProperties makes ScanEntity accept properties with "//" comments and
+// trailing commas in objects and arrays, which are removed before the
+// properties are stored. It is meant for hand-written inputs such as test
+// fixtures and seed data; properties from AgensGraph are always strict JSON,
+// which is what ScanEntity accepts by default.
+func LenientProperties() ScanOption {
+	return func(o *scanOptions) {
+		o.lenient = true
+	}
+}
+
+// NonFiniteNumbers makes ScanEntity accept NaN, Infinity, and -Infinity in
+// properties, which are not allowed in JSON, and store them as math.NaN() and
+// math.Inf(1) and math.Inf(-1). It applies to BasicVertex, BasicEdge, and
+// entities that embed them. Entities that store properties in other ways
+// still get the properties as they are.
+func NonFiniteNumbers() ScanOption {
+	return func(o *scanOptions) {
+		o.nonFinite = true
+	}
+}
+
+// LowercaseKeys makes ScanEntity lowercase the top-level keys of properties
+// before it stores them, so that "Name" and "name" end up as the same key of
+// the Properties map of BasicVertex and BasicEdge and the same key for
+// PropertiesRequirer. If keys differ only in case, the last one wins as for
+// duplicate keys; Strict still reports the original duplicates only.
+//
+// encoding/json already matches keys to struct fields case-insensitively, so
+// the option matters most for maps and PropertiesSaver.
+func LowercaseKeys() ScanOption {
+	return func(o *scanOptions) {
+		o.lowercaseKeys = true
+	}
+}
+
+// ExtendedJSONNumbers makes ScanEntity replace the MongoDB extended JSON
+// wrappers of numbers in properties with the numbers before it stores them, so
+// that {"n": {"$numberLong": "123"}} is stored as {"n": 123}. The supported
+// wrappers are "$numberInt", "$numberLong", "$numberDouble", and
+// "$numberDecimal", whose values must be strings of numbers. An object is a
+// wrapper only if it has a single key.
+//
+// "$numberDouble" may wrap "NaN", "Infinity", and "-Infinity" only with
+// NonFiniteNumbers. The properties are re-encoded if they have any wrapper,
+// which sorts the keys of the objects.
+func ExtendedJSONNumbers() ScanOption {
+	return func(o *scanOptions) {
+		o.extendedJSON = true
+	}
+}
+
+// PropertiesDecodeFunc stores the properties b in entity. It is registered in
+// PropertiesRegistry for a label.
+type PropertiesDecodeFunc func(entity Entity, b []byte) error
+
+// PropertiesRegistry maps labels to the functions that decode the properties
+// of the entities with the labels, so that the decoding logic for each label
+// is kept in one place instead of in every entity type. It is safe for
+// concurrent use.
+type PropertiesRegistry struct {
+	mu sync.RWMutex
+	m  map[string]PropertiesDecodeFunc
+}
+
+// NewPropertiesRegistry returns an empty PropertiesRegistry.
+func NewPropertiesRegistry() *PropertiesRegistry {
+	return &PropertiesRegistry{m: make(map[string]PropertiesDecodeFunc)}
+}
+
+// Register registers f for label, replacing the function registered before.
+// label is the label as it appears in the text form of entities. If f is nil,
+// the function for label is removed.
+func (r *PropertiesRegistry) Register(label string, f PropertiesDecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f == nil {
+		delete(r.m, label)
+	} else {
+		r.m[label] = f
+	}
+}
+
+func (r *PropertiesRegistry) lookup(label string) PropertiesDecodeFunc {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.m[label]
+}
+
+// WithPropertiesRegistry makes ScanEntity decode the properties of an entity
+// with the function registered in r for the label of the entity. It is used
+// only for entities that store the properties by ScanEntity; if an entity
+// implements PropertiesSaver or PropertiesMerger, the interface wins over r.
+// If no function is registered for the label, the properties are unmarshaled
+// as usual.
+func WithPropertiesRegistry(r *PropertiesRegistry) ScanOption {
+	return func(o *scanOptions) {
+		o.registry = r
+	}
+}
+
+// propertiesSetter is implemented by entities whose properties can be set
+// as a map directly.
+type propertiesSetter interface {
+	setProperties(m map[string]interface{})
+}
+
+// UseNumber makes ScanEntity decode numbers in properties as json.Number
+// instead of float64 when it stores the properties in an entity by calling
+// json.Unmarshal. It has no effect on entities that implement PropertiesSaver.
+func UseNumber() ScanOption {
+	return func(o *scanOptions) {
+		o.useNumber = true
+	}
+}
+
+// SkipEndpoints makes ScanEntity leave Start and End of EdgeCore NULL instead
+// of reading the IDs of the start and end vertices of edges. It saves the work
+// when only the label and the ID of edges are needed, such as when counting
+// edges by label. The text form of the IDs is still checked.
+func SkipEndpoints() ScanOption {
+	return func(o *scanOptions) {
+		o.skipEndpoints = true
+	}
+}
+
+// InternLabels makes ScanEntity reuse the same string for the same label
+// instead of allocating a new string for every entity. It saves allocations
+// and memory when many entities share a handful of labels.
+//
+// The labels are kept for the lifetime of the options, so it is most useful
+// with a Decoder or ScanEntities. The table is guarded for concurrent use of
+// a Decoder.
+func InternLabels() ScanOption {
+	return func(o *scanOptions) {
+		o.labels = &labelTable{m: make(map[string]string)}
+	}
+}
+
+type labelTable struct {
+	mu sync.RWMutex
+	m  map[string]string
+}
+
+func (t *labelTable) intern(b []byte) string {
+	t.mu.RLock()
+	s, ok := t.m[string(b)]
+	t.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok = t.m[string(b)]
+	if !ok {
+		s = string(b)
+		t.m[s] = s
+	}
+	return s
+}
+
+// label returns b as a label, interned if InternLabels is set.
+func (o scanOptions) label(b []byte) string {
+	if o.labels == nil {
+		return string(b)
+	}
+	return o.labels.intern(b)
+}
+
+// OnError makes ScanEntity, ScanEntityN, ScanEntities, and ScanPath call f
+// with the error and the input when they fail, for example to count malformed
+// rows. input is nil if the error is not about the text from the database
+// driver, such as an error returned by SaveEntity for an element of an array.
+func OnError(f func(err error, input []byte)) ScanOption {
+	return func(o *scanOptions) {
+		o.onError = f
+	}
+}
+
+// OnEntity makes ScanEntity, ScanEntityN, ScanEntities, and ScanPath call f
+// with the label of every entity they read, before the entity is stored. NULL
+// entities and entities rejected by ValidateLabels are not reported.
+func OnEntity(f func(label string)) ScanOption {
+	return func(o *scanOptions) {
+		o.onEntity = f
+	}
+}
+
+// reportError calls the OnError hook if err is not nil. It returns err.
+func (o scanOptions) reportError(err error, input []byte) error {
+	if err != nil && o.onError != nil {
+		o.onError(err, input)
+	}
+	return err
+}
+
+// countBytes counts n bytes of input for Stats.
+func (o scanOptions) countBytes(n int) {
+	if o.stats != nil {
+		o.stats.bytes.Add(int64(n))
+	}
+}
+
+// reportEntity calls the OnEntity hook and counts the entity for Stats.
+func (o scanOptions) reportEntity(d *entityData) {
+	if o.stats != nil {
+		o.stats.elements.Add(1)
+	}
+	if o.onEntity != nil {
+		o.onEntity(d.label())
+	}
+}
+
+// identifierPattern matches an unquoted or a double-quoted identifier.
+const identifierPattern = `(?:[\pL_][\pL\pN_$]*|"(?:[^"]|"")+")`
+
+// labelRegexp matches a label that follows the identifier rules of AgensGraph,
+// optionally qualified by a graph name.
+var labelRegexp = regexp.MustCompile(`^` + identifierPattern + `(?:\.` + identifierPattern + `)?$`)
+
+// ValidateLabels makes ScanEntity return an error if the label of an entity
+// does not match re. If re is nil, a label must follow the identifier rules
+// of AgensGraph; it must be an identifier that begins with a letter or an
+// underscore followed by letters, digits, underscores, and dollar signs, or a
+// double-quoted identifier, optionally qualified by a graph name in the same
+// form (see SplitLabel).
+//
+// Labels are not validated by default. It is a defense-in-depth measure for
+// data from untrusted sources, and is typically given to NewDecoder.
+func ValidateLabels(re *regexp.Regexp) ScanOption {
+	if re == nil {
+		re = labelRegexp
+	}
+	return func(o *scanOptions) {
+		o.labelRegexp = re
+	}
+}
+
 // ScanEntity reads an entity for vertex or edge from src and stores the result
-// in the given entity.
+// in the given entity. opts may be given to change the default behavior.
 //
-// An error will be returned if the type of src is not []byte, or src is
+// src may be []byte, string, sql.RawBytes, fmt.Stringer, or any type whose
+// underlying type is []byte or string.
+//
+// An error will be returned if the type of src is none of them, or src is
 // invalid for the given entity.
-func ScanEntity(src interface{}, entity Entity) error {
+func ScanEntity(src interface{}, entity Entity, opts ...ScanOption) error {
+	return tracedScanEntity(src, entity, newScanOptions(opts))
+}
+
+func tracedScanEntity(src interface{}, entity Entity, o scanOptions) error {
+	return o.traced("ag.ScanEntity", src, entity, func() error {
+		return scanEntity(src, entity, o)
+	})
+}
+
+func scanEntity(src interface{}, entity Entity, o scanOptions) error {
 	switch src := src.(type) {
-	case []byte:
-		if len(src) < 1 {
-			return fmt.Errorf("invalid source for entity: %v", src)
-		}
-		d, err := entity.readEntity(src)
-		if err != nil {
-			return err
-		}
-		return saveEntityData(d, entity)
 	case *entityData:
-		return saveEntityData(src, entity)
+		return o.reportError(saveEntityData(src, entity, o), nil)
 	case nil:
 		return entity.SaveEntity(false, nil)
+	}
+
+	b, ok := textSource(src)
+	if !ok {
+		return o.reportError(fmt.Errorf("invalid source for entity: %T", src), nil)
+	}
+	o.countBytes(len(b))
+	if len(b) < 1 {
+		return o.reportError(fmt.Errorf("invalid source for entity: %v", b), b)
+	}
+
+	d, err := entity.readEntity(b, o)
+	if err == nil {
+		err = saveEntityData(d, entity, o)
+	}
+	return o.reportError(err, b)
+}
+
+// AssembleEntity stores core and props in entity as ScanEntity does for an
+// entity read from its text form. It is for queries that return the parts of
+// entities in separate columns, such as `RETURN id(v), label(v), properties(v)`,
+// to avoid transferring the whole text of the entities.
+//
+// core must be VertexCore for an entity for vertex and EdgeCore for an entity
+// for edge. props is the JSON object of the properties; nil is the same as an
+// empty object.
+func AssembleEntity(core interface{}, props []byte, entity Entity, opts ...ScanOption) error {
+	switch core.(type) {
+	case VertexCore, EdgeCore:
 	default:
-		return fmt.Errorf("invalid source for entity: %T", src)
+		return fmt.Errorf("invalid entity core: %T", core)
+	}
+
+	if props == nil {
+		props = []byte("{}")
+	}
+	o := newScanOptions(opts)
+	return o.reportError(saveEntityData(&entityData{core: core, properties: props}, entity, o), props)
+}
+
+// ScanRowEntity reads an entity for vertex or edge from the column named column
+// of the current row of rows and stores the result in the given entity. It
+// must be called after rows.Next like rows.Scan.
+//
+// An error will be returned if there is no such column, or the value of the
+// column is invalid for the given entity.
+func ScanRowEntity(rows *sql.Rows, column string, entity Entity, opts ...ScanOption) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	idx := -1
+	dest := make([]interface{}, len(cols))
+	for i, c := range cols {
+		if c == column && idx < 0 {
+			idx = i
+		}
+		dest[i] = new(interface{})
+	}
+	if idx < 0 {
+		return fmt.Errorf("no column named %q", column)
+	}
+
+	err = rows.Scan(dest...)
+	if err != nil {
+		return err
+	}
+
+	return scanEntity(*dest[idx].(*interface{}), entity, newScanOptions(opts))
+}
+
+// CollectById reads an entity from the only column of each of the remaining
+// rows and returns the entities indexed by the IDs idOf returns. newElem must
+// return a new entity for each row. An entity whose ID is NULL, such as a NULL
+// entity, is not collected. If IDs are duplicated, the last one is kept.
+//
+// CollectById does not close rows.
+func CollectById[T Entity](rows *sql.Rows, newElem func() T, idOf func(T) GraphId, opts ...ScanOption) (map[GraphId]T, error) {
+	o := newScanOptions(opts)
+
+	m := make(map[GraphId]T)
+	for rows.Next() {
+		var src interface{}
+		err := rows.Scan(&src)
+		if err != nil {
+			return nil, err
+		}
+
+		e := newElem()
+		err = scanEntity(src, e, o)
+		if err != nil {
+			return nil, err
+		}
+
+		id := idOf(e)
+		if id.Valid {
+			m[id] = e
+		}
+	}
+	err := rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ScanEntityN reads an entity for vertex or edge from the beginning of b and
+// stores the result in the given entity. Unlike ScanEntity, b may have extra
+// bytes after the entity. It returns the number of bytes read from b.
+//
+// An error will be returned if b does not begin with a valid entity for the
+// given entity.
+func ScanEntityN(b []byte, entity Entity, opts ...ScanOption) (advance int, err error) {
+	return tracedScanEntityN(b, entity, newScanOptions(opts))
+}
+
+func tracedScanEntityN(b []byte, entity Entity, o scanOptions) (advance int, err error) {
+	err = o.traced("ag.ScanEntityN", b, entity, func() error {
+		advance, err = scanEntityN(b, entity, o)
+		return err
+	})
+	return
+}
+
+func scanEntityN(b []byte, entity Entity, o scanOptions) (advance int, err error) {
+	if len(b) < 1 {
+		return 0, o.reportError(fmt.Errorf("invalid source for entity: %v", b), b)
+	}
+
+	advance, d, err := entity.readElement(b, o)
+	if err != nil {
+		return 0, o.reportError(err, b)
+	}
+	o.countBytes(advance)
+	if d == nil {
+		return advance, entity.SaveEntity(false, nil)
 	}
+	return advance, o.reportError(saveEntityData(d, entity, o), b[:advance])
 }
 
-func saveEntityData(d *entityData, entity Entity) error {
+func saveEntityData(d *entityData, entity Entity, o scanOptions) error {
 	if d == nil {
 		panic("invalid entity data: nil")
 	}
 
+	if o.labelRegexp != nil {
+		label := d.label()
+		if !o.labelRegexp.MatchString(label) {
+			return fmt.Errorf("invalid label: %q", label)
+		}
+	}
+
+	o.reportEntity(d)
+
+	if d.skipProperties || o.skipsProperties(d) {
+		return entity.SaveEntity(true, d.core)
+	}
+
+	if o.lenient {
+		d = &entityData{core: d.core, properties: stripJSONExtensions(d.properties)}
+	}
+
+	if o.strict {
+		props := d.properties
+		if o.nonFinite {
+			props = quoteNonFinite(props)
+		}
+		err := checkDuplicateKeys(props)
+		if err != nil {
+			return errors.New("invalid properties: " + err.Error())
+		}
+	}
+
+	if o.lowercaseKeys {
+		d = &entityData{core: d.core, properties: lowercaseJSONKeys(d.properties)}
+	}
+
+	if o.extendedJSON {
+		props, err := coerceExtendedJSON(d.properties, o.nonFinite)
+		if err != nil {
+			return errors.New("invalid properties: " + err.Error())
+		}
+		d = &entityData{core: d.core, properties: props}
+	}
+
 	err := entity.SaveEntity(true, d.core)
 	if err != nil {
 		return err
 	}
 
-	if p, ok := entity.(PropertiesSaver); ok {
-		err = p.SaveProperties(d.properties)
+	props := d.properties
+	if o.copyProperties {
+		props = CopyProperties(props)
+	}
+
+	if saved, serr := saveTextProperties(entity, props); saved {
+		err = serr
+	} else if s, ok := entity.(propertiesSetter); ok && o.nonFinite {
+		var m map[string]interface{}
+		m, err = unmarshalNonFinite(props)
+		if err == nil {
+			s.setProperties(m)
+		}
+	} else if m, ok := entity.(PropertiesMerger); ok {
+		err = m.MergeProperties(props)
+	} else if p, ok := entity.(PropertiesSaver); ok {
+		err = p.SaveProperties(props)
+	} else if f := o.registry.lookup(d.label()); f != nil {
+		err = f(entity, props)
 	} else {
-		err = json.Unmarshal(d.properties, entity)
+		err = unmarshalProperties(d.properties, entity, o)
+		if u, ok := entity.(UnknownPropertiesSaver); ok && err == nil {
+			var keys []string
+			keys, err = unknownPropertyKeys(d.properties, entity)
+			if err == nil {
+				u.SaveUnknown(keys)
+			}
+		}
 	}
-	return err
+	if err != nil {
+		return fmt.Errorf("saving properties for %s: %w", d, err)
+	}
+
+	if o.nonFinite {
+		// make the properties valid JSON for the checks below
+		d = &entityData{core: d.core, properties: quoteNonFinite(d.properties)}
+	}
+
+	if r, ok := entity.(PropertiesRequirer); ok {
+		err = checkRequiredProperties(d.properties, r.RequiredProperties())
+		if err != nil {
+			return err
+		}
+	}
+
+	if r, ok := entity.(ReservedPropertiesSaver); ok {
+		m, err := readReservedProperties(d.properties)
+		if err != nil {
+			return err
+		}
+		return r.SaveReservedProperties(m)
+	}
+	return nil
+}
+
+// unknownPropertyKeys returns the sorted keys in b that do not match any field
+// of entity.
+func unknownPropertyKeys(b []byte, entity Entity) ([]string, error) {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := jsonFieldNames(reflect.TypeOf(entity))
+	var keys []string
+	for k := range raw {
+		if !fields[strings.ToLower(k)] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+var jsonFieldNamesCache sync.Map // map[reflect.Type]map[string]bool
+
+// jsonFieldNames returns the lowercased names of the fields of struct t, or of
+// the struct t points to, that encoding/json decodes into.
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	if names, ok := jsonFieldNamesCache.Load(t); ok {
+		return names.(map[string]bool)
+	}
+
+	names := make(map[string]bool)
+	st := t
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	if st.Kind() == reflect.Struct {
+		collectJSONFieldNames(st, names, make(map[reflect.Type]bool))
+	}
+	jsonFieldNamesCache.Store(t, names)
+	return names
+}
+
+func collectJSONFieldNames(t reflect.Type, names map[string]bool, visited map[reflect.Type]bool) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// fields of untagged embedded structs are promoted
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectJSONFieldNames(ft, names, visited)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		names[strings.ToLower(name)] = true
+	}
+}
+
+func readReservedProperties(b []byte) (map[string]interface{}, error) {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, errors.New("invalid properties: " + err.Error())
+	}
+
+	m := make(map[string]interface{})
+	for k, v := range raw {
+		if !strings.HasPrefix(k, "_") {
+			continue
+		}
+		var val interface{}
+		err = json.Unmarshal(v, &val)
+		if err != nil {
+			return nil, errors.New("invalid properties: " + err.Error())
+		}
+		m[k] = val
+	}
+	return m, nil
+}
+
+func unmarshalProperties(b []byte, entity Entity, o scanOptions) error {
+	if !o.useNumber {
+		return json.Unmarshal(b, entity)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(entity)
+}
+
+func checkRequiredProperties(b []byte, keys []string) error {
+	if len(keys) < 1 {
+		return nil
+	}
+
+	var m map[string]json.RawMessage
+	err := json.Unmarshal(b, &m)
+	if err != nil {
+		return errors.New("invalid properties: " + err.Error())
+	}
+
+	var missing []string
+	for _, k := range keys {
+		if _, ok := m[k]; !ok {
+			missing = append(missing, strconv.Quote(k))
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("missing required properties: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// jsonEntity is the JSON object of a vertex or an edge, which AgensGraph
+// produces where a vertex or an edge is converted to JSON, such as a vertex
+// stored as a property value or in a map:
+//
+//	{"label": "person", "id": "3.1", "properties": {"name": "go"}}
+//	{"label": "knows", "id": "4.1", "start": "3.1", "end": "3.2", "properties": {}}
+type jsonEntity struct {
+	Label      *string
+	Id         json.RawMessage
+	Start      json.RawMessage
+	End        json.RawMessage
+	Properties json.RawMessage
+}
+
+// entityData returns the entity data of e. ok is false if e is not a vertex or
+// an edge; it must have a label, a graphid for id, and an object for
+// properties. err is not nil if e has start or end but is not a valid edge.
+func (e *jsonEntity) entityData(o scanOptions) (d *entityData, ok bool, err error) {
+	var id GraphId
+	if e.Label == nil || len(e.Id) < 1 || id.UnmarshalJSON(e.Id) != nil || !id.Valid {
+		return nil, false, nil
+	}
+	if len(e.Properties) < 1 || e.Properties[0] != '{' {
+		return nil, false, nil
+	}
+	label := o.label([]byte(*e.Label))
+
+	if e.Start == nil && e.End == nil {
+		return &entityData{core: VertexCore{label, id}, properties: []byte(e.Properties)}, true, nil
+	}
+
+	var start, end GraphId
+	if len(e.Start) < 1 || start.UnmarshalJSON(e.Start) != nil || !start.Valid {
+		return nil, true, fmt.Errorf("invalid edge start ID: %s", e.Start)
+	}
+	if len(e.End) < 1 || end.UnmarshalJSON(e.End) != nil || !end.Valid {
+		return nil, true, fmt.Errorf("invalid edge end ID: %s", e.End)
+	}
+	c := EdgeCore{label, id, start, end}
+	if o.skipEndpoints {
+		c.Start, c.End = nullGraphId, nullGraphId
+	}
+	return &entityData{core: c, properties: []byte(e.Properties)}, true, nil
+}
+
+// NestedEntity can be used as a field type of properties to read a vertex or
+// an edge nested in the properties of another entity.
+//
+// A nested entity is expected to be the JSON object that AgensGraph produces
+// for a vertex or an edge stored as a property value, which has "label", "id",
+// and "properties", and also "start" and "end" for an edge. AgValue and
+// ScanPath read the same object. JSON null is read as a NULL entity.
+//
+// Entity must be set to the entity which will store the result before
+// decoding.
+type NestedEntity struct {
+	Entity Entity
+}
+
+// UnmarshalJSON implements the encoding/json Unmarshaler interface. It calls
+// ScanEntity.
+func (n *NestedEntity) UnmarshalJSON(b []byte) error {
+	if n.Entity == nil {
+		return errors.New("nested entity: nil Entity")
+	}
+
+	if string(b) == "null" {
+		return ScanEntity(nil, n.Entity)
+	}
+
+	var e jsonEntity
+	err := json.Unmarshal(b, &e)
+	if err != nil {
+		return errors.New("invalid nested entity: " + err.Error())
+	}
+	d, ok, err := e.entityData(scanOptions{})
+	if !ok {
+		return errors.New("invalid nested entity: not a vertex or an edge")
+	}
+	if err != nil {
+		return errors.New("invalid nested entity: " + err.Error())
+	}
+	return ScanEntity(d, n.Entity)
 }
@@ -19,23 +19,31 @@ package ag
 import (
 	"bytes"
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
 	"strconv"
 )
 
 // GraphId is a unique ID for a vertex and an edge.
+//
+// GraphId is comparable and can be used as a map key. It keeps the text form
+// without leading zeros, so "3.01" and "3.1" are the same GraphId.
 type GraphId struct {
 	// Valid is true if GraphId is not NULL
 	Valid bool
 
-	b []byte
+	s string
 }
 
 var nullGraphId = GraphId{}
 
-var graphIdRegexp = regexp.MustCompile(`^(\d+)\.(\d+)$`)
+// InvalidGraphId is the zero value of GraphId, which is NULL. It can be used
+// where an explicit "no ID" is needed.
+//
+// Note that the label ID and the local ID of a valid graphid are never 0, so
+// "0.0" is not a valid graphid at all; NewGraphId rejects it.
+var InvalidGraphId GraphId
 
 // NewGraphId returns GraphId of str if str is between "1.1" and
 // "65535.281474976710656". If str is "NULL", it returns GraphId whose Valid is
@@ -45,12 +53,12 @@ func NewGraphId(str string) (GraphId, error) {
 		return nullGraphId, nil
 	}
 
-	err := validateGraphId(str)
+	key, err := parseGraphId(str)
 	if err != nil {
 		return GraphId{}, err
 	}
 
-	return GraphId{true, []byte(str)}, nil
+	return GraphId{true, graphIdString(str, key)}, nil
 }
 
 const (
@@ -58,29 +66,142 @@ const (
 	localBit = 48
 )
 
-func validateGraphId(str string) error {
-	m := graphIdRegexp.FindStringSubmatch(str)
-	if m == nil {
-		return fmt.Errorf("bad graphid representation: %q", str)
+// parseGraphId returns the packed value of the text form of graphid in b. It
+// reads the digits directly and does not allocate unless b is invalid.
+func parseGraphId[T string | []byte](b T) (uint64, error) {
+	i := 0
+	label, ok := parseGraphIdPart(b, &i, 1<<labelBit-1)
+	if i == 0 || i >= len(b) || b[i] != '.' {
+		return 0, fmt.Errorf("bad graphid representation: %q", b)
+	}
+	if !ok || label == 0 {
+		return 0, fmt.Errorf("invalid label ID: %s", b[:i])
 	}
 
-	i, err := strconv.ParseUint(m[1], 10, labelBit)
-	if err != nil {
-		return errors.New("invalid label ID: " + err.Error())
+	i++
+	j := i
+	local, ok := parseGraphIdPart(b, &i, 1<<localBit-1)
+	if i == j || i != len(b) {
+		return 0, fmt.Errorf("bad graphid representation: %q", b)
+	}
+	if !ok || local == 0 {
+		return 0, fmt.Errorf("invalid local ID: %s", b[j:])
+	}
+
+	return label<<localBit | local, nil
+}
+
+// parseGraphIdPart reads the decimal digits of b from *i and advances *i past
+// them. ok is false if the number is greater than max.
+func parseGraphIdPart[T string | []byte](b T, i *int, max uint64) (n uint64, ok bool) {
+	ok = true
+	for ; *i < len(b) && '0' <= b[*i] && b[*i] <= '9'; *i++ {
+		if n > (max-uint64(b[*i]-'0'))/10 {
+			ok = false
+			continue
+		}
+		n = n*10 + uint64(b[*i]-'0')
+	}
+	return n, ok
+}
+
+// graphIdString returns b, the text form of the graphid whose packed value is
+// key, as a string without leading zeros.
+func graphIdString[T string | []byte](b T, key uint64) string {
+	for i := 0; i < len(b); i++ {
+		if b[i] == '.' {
+			if b[0] != '0' && b[i+1] != '0' {
+				return string(b)
+			}
+			break
+		}
 	}
-	if i == 0 {
-		return fmt.Errorf("invalid label ID: %d", i)
+	return formatGraphId(key)
+}
+
+// formatGraphId returns the text form of the graphid whose packed value is key.
+func formatGraphId(key uint64) string {
+	return strconv.FormatUint(key>>localBit, 10) + "." + strconv.FormatUint(key&(1<<localBit-1), 10)
+}
+
+// AsGraphId returns GraphId of v if v is a graphid embedded in a JSON value
+// such as properties or a map returned by a Cypher expression, after it has
+// been decoded by encoding/json.
+//
+// A graphid is embedded in a JSON value as a string of its text form (e.g.
+// "3.1"), so v is recognized as a graphid if v is a string or json.Number
+// that NewGraphId accepts, except "NULL". Otherwise, ok is false.
+func AsGraphId(v interface{}) (gid GraphId, ok bool) {
+	var str string
+	switch v := v.(type) {
+	case string:
+		str = v
+	case json.Number:
+		str = string(v)
+	default:
+		return
 	}
 
-	i, err = strconv.ParseUint(m[2], 10, localBit)
+	key, err := parseGraphId(str)
 	if err != nil {
-		return errors.New("invalid local ID: " + err.Error())
+		return
 	}
-	if i == 0 {
-		return fmt.Errorf("invalid local ID: %d", i)
+	return GraphId{true, graphIdString(str, key)}, true
+}
+
+// Key returns the packed 64-bit value of gid, which is the same as the
+// internal representation of graphid in AgensGraph; the label ID occupies the
+// upper 16 bits and the local ID occupies the lower 48 bits. The value is
+// unique for each valid GraphId and can be used as a compact cache key.
+//
+// Key returns 0 if gid is NULL.
+func (gid GraphId) Key() uint64 {
+	if !gid.Valid {
+		return 0
 	}
 
-	return nil
+	key, _ := parseGraphId(gid.s)
+	return key
+}
+
+// LabelIdRange returns the minimum and maximum GraphIds of the label whose ID
+// is labelId. Since the local ID is a 48-bit number that starts from 1, min is
+// "labelId.1" and max is "labelId.281474976710655". They can be used for a
+// range clause scoped to the label such as `id(v) >= $1 AND id(v) <= $2`.
+//
+// Both are NULL if labelId is 0, which is not a valid label ID.
+func LabelIdRange(labelId uint16) (min, max GraphId) {
+	if labelId == 0 {
+		return nullGraphId, nullGraphId
+	}
+
+	l := strconv.FormatUint(uint64(labelId), 10)
+	min = GraphId{true, l + ".1"}
+	max = GraphId{true, l + "." + strconv.FormatUint(1<<localBit-1, 10)}
+	return
+}
+
+// IsZero reports whether gid is the zero value, which is NULL.
+func (gid GraphId) IsZero() bool {
+	return !gid.Valid
+}
+
+// Next returns the GraphId that has the same label ID as gid and the local ID
+// next to that of gid. It is useful for generating IDs of test data.
+//
+// An error will be returned if gid is NULL or the local ID of gid is already
+// the maximum (281474976710655).
+func (gid GraphId) Next() (GraphId, error) {
+	if !gid.Valid {
+		return GraphId{}, errors.New("next of NULL graphid")
+	}
+
+	key := gid.Key()
+	if key&(1<<localBit-1) == 1<<localBit-1 {
+		return GraphId{}, fmt.Errorf("local ID overflow: %s", gid.String())
+	}
+
+	return GraphId{true, formatGraphId(key + 1)}, nil
 }
 
 // Equal reports whether gid and x are the same GraphId.
@@ -88,50 +209,217 @@ func (gid GraphId) Equal(x GraphId) bool {
 	if !gid.Valid || !x.Valid {
 		return false
 	}
-	return bytes.Equal(gid.b, x.b)
+	return gid.s == x.s
 }
 
+// String returns the text form of gid (e.g. "3.1"), or "NULL" if gid is NULL.
 func (gid GraphId) String() string {
 	if gid.Valid {
-		return string(gid.b)
+		return gid.s
 	} else {
 		return "NULL"
 	}
 }
 
-// Scan implements the database/sql Scanner interface.
+// Format returns gid formatted by f for display. It returns the same as String
+// if gid is NULL or f is nil. For example, with the LabelCache of a graph,
+// Format returns "person:1" instead of "3.1" if the label ID 3 is "person".
+func (gid GraphId) Format(f GraphIdFormatter) string {
+	if !gid.Valid || f == nil {
+		return gid.String()
+	}
+	return f.FormatGraphId(gid)
+}
+
+// PaddedString returns the text form of gid with the label ID zero-padded to
+// 5 digits and the local ID zero-padded to 15 digits (e.g.
+// "00003.000000000000001"), which are the widths of the maximum 16-bit and
+// 48-bit numbers, 65535 and 281474976710655. The strings sort lexically in the
+// same order as the GraphIds sort numerically, so they suit sorted keys such
+// as object names. It returns "NULL" if gid is NULL.
+func (gid GraphId) PaddedString() string {
+	if !gid.Valid {
+		return "NULL"
+	}
+
+	key := gid.Key()
+	return fmt.Sprintf("%05d.%015d", key>>localBit, key&(1<<localBit-1))
+}
+
+// GraphIdFormatter formats GraphId for display. It is passed to
+// (GraphId).Format, so it only changes the GraphIds formatted with it.
+type GraphIdFormatter interface {
+	// FormatGraphId is called with valid GraphIds only.
+	FormatGraphId(gid GraphId) string
+}
+
+// LabelId returns the label ID of gid, or 0 if gid is NULL.
+func (gid GraphId) LabelId() uint16 {
+	return uint16(gid.Key() >> localBit)
+}
+
+// AppendTo appends the text form of gid, the same as String returns, to buf
+// and returns the extended buffer.
+func (gid GraphId) AppendTo(buf []byte) []byte {
+	if gid.Valid {
+		return append(buf, gid.s...)
+	} else {
+		return append(buf, nullElementValue...)
+	}
+}
+
+// MarshalJSON implements the encoding/json Marshaler interface. gid is
+// encoded as a JSON string of its text form, or null if it is NULL.
+func (gid GraphId) MarshalJSON() ([]byte, error) {
+	if !gid.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(gid.s)
+}
+
+// UnmarshalJSON implements the encoding/json Unmarshaler interface. It accepts
+// a JSON string or number of the text form of graphid, and null for NULL. An
+// integer JSON number is taken as the packed 64-bit value that Key returns;
+// it is parsed as an integer, so values above 2^53 keep their precision.
+func (gid *GraphId) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		gid.Valid, gid.s = false, ""
+		return nil
+	}
+
+	var str string
+	if len(b) > 0 && b[0] == '"' {
+		err := json.Unmarshal(b, &str)
+		if err != nil {
+			return errors.New("invalid graphid: " + err.Error())
+		}
+	} else if bytes.IndexByte(b, '.') < 0 {
+		key, err := strconv.ParseUint(string(b), 10, 64)
+		if err != nil {
+			return errors.New("invalid graphid: " + err.Error())
+		}
+		str = formatGraphId(key)
+	} else {
+		str = string(b)
+	}
+
+	key, err := parseGraphId(str)
+	if err != nil {
+		return err
+	}
+
+	gid.Valid, gid.s = true, graphIdString(str, key)
+	return nil
+}
+
+// Scan implements the database/sql Scanner interface. The text form of graphid
+// may be wrapped in parentheses, as in the text form of a record that has a
+// single graphid.
 func (gid *GraphId) Scan(src interface{}) error {
 	if src == nil {
-		gid.Valid, gid.b = false, nil
+		gid.Valid, gid.s = false, ""
 		return nil
 	}
 
-	b, ok := src.([]byte)
-	if !ok {
+	var key uint64
+	var err error
+	switch src := src.(type) {
+	case []byte:
+		src = stripRecordWrapper(src)
+		key, err = scanGraphId(src)
+		if err == nil {
+			gid.s = graphIdString(src, key)
+		}
+	case string:
+		src = stripRecordWrapper(src)
+		key, err = scanGraphId(src)
+		if err == nil {
+			gid.s = graphIdString(src, key)
+		}
+	default:
 		return fmt.Errorf("invalid source for graphid: %T", src)
 	}
-	if len(b) < 1 {
-		return fmt.Errorf("invalid source for graphid: %v", b)
-	}
-
-	err := validateGraphId(string(b))
 	if err != nil {
 		return err
 	}
 
-	gid.Valid, gid.b = true, append([]byte(nil), b...)
+	gid.Valid = true
 	return nil
 }
 
+// stripRecordWrapper returns b without the parentheses around it, which wrap a
+// graphid in the text form of a record that has a single graphid.
+func stripRecordWrapper[T string | []byte](b T) T {
+	if len(b) > 1 && b[0] == '(' && b[len(b)-1] == ')' {
+		return b[1 : len(b)-1]
+	}
+	return b
+}
+
+// scanGraphId returns the packed value of the text form of graphid in b, which
+// is the source of Scan.
+func scanGraphId[T string | []byte](b T) (uint64, error) {
+	if len(b) < 1 {
+		return 0, fmt.Errorf("invalid source for graphid: %q", b)
+	}
+	return parseGraphId(b)
+}
+
 // Value implements the database/sql/driver Valuer interface.
 func (gid GraphId) Value() (driver.Value, error) {
 	if gid.Valid {
-		return gid.b, nil
+		return []byte(gid.s), nil
 	} else {
 		return nil, nil
 	}
 }
 
+// GraphIdKey is the packed 64-bit value of graphid, which Key returns. It can
+// be used to scan graphid without allocating, unlike GraphId that keeps the
+// text form, when many IDs are scanned only to be compared or used as keys.
+// NULL is scanned as 0.
+type GraphIdKey uint64
+
+// Scan implements the database/sql Scanner interface. It accepts the same
+// sources as (GraphId).Scan and does not allocate for a valid graphid.
+func (k *GraphIdKey) Scan(src interface{}) error {
+	if src == nil {
+		*k = 0
+		return nil
+	}
+
+	var key uint64
+	var err error
+	switch src := src.(type) {
+	case []byte:
+		key, err = scanGraphId(stripRecordWrapper(src))
+	case string:
+		key, err = scanGraphId(stripRecordWrapper(src))
+	default:
+		return fmt.Errorf("invalid source for graphid: %T", src)
+	}
+	if err != nil {
+		return err
+	}
+	*k = GraphIdKey(key)
+	return nil
+}
+
+// Value implements the database/sql/driver Valuer interface. It returns the
+// same value as (GraphId).Value of k.GraphId(), so 0 is NULL.
+func (k GraphIdKey) Value() (driver.Value, error) {
+	return k.GraphId().Value()
+}
+
+// GraphId returns GraphId of k, or NULL if k is 0 or not a valid graphid.
+func (k GraphIdKey) GraphId() GraphId {
+	l, r := uint64(k)>>localBit, uint64(k)&(1<<localBit-1)
+	if l == 0 || r == 0 {
+		return nullGraphId
+	}
+	return GraphId{true, formatGraphId(uint64(k))}
+}
+
 type graphIdArray []GraphId
 
 // separated by comma (see graphid in pg_type.h)
@@ -152,6 +440,9 @@ func (a *graphIdArray) Scan(src interface{}) error {
 	}
 
 	// remove surrounding braces
+	if len(b) < 2 || b[0] != '{' || b[len(b)-1] != '}' {
+		return fmt.Errorf("bad _graphid representation: %s", b)
+	}
 	b = b[1 : len(b)-1]
 
 	// bytes.Split() returns [][]byte{[]byte{}} even if len(b) < 1.
@@ -166,7 +457,7 @@ func (a *graphIdArray) Scan(src interface{}) error {
 
 	gids := make([]GraphId, len(ss))
 	for i, s := range ss {
-		if bytes.Equal(s, nullElementValue) {
+		if isNullElement(s) {
 			gids[i] = nullGraphId
 			continue
 		}
@@ -194,12 +485,7 @@ func (a graphIdArray) Value() (driver.Value, error) {
 			if i > 0 {
 				b = append(b, graphIdSeparator)
 			}
-			if a[i].Valid {
-				val, _ := a[i].Value()
-				b = append(b, val.([]byte)...)
-			} else {
-				b = append(b, nullElementValue...)
-			}
+			b = a[i].AppendTo(b)
 		}
 		b = append(b, '}')
 
@@ -18,6 +18,10 @@ package ag
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 	"testing"
 )
 
@@ -55,6 +59,7 @@ func TestGraphIdEqual(t *testing.T) {
 		{mustNewGraphId("1.1"), mustNewGraphId("NULL"), false},
 		{mustNewGraphId("1.1"), mustNewGraphId("1.1"), true},
 		{mustNewGraphId("1.1"), mustNewGraphId("65535.281474976710655"), false},
+		{mustNewGraphId("3.01"), mustNewGraphId("3.1"), true},
 	}
 	for _, c := range tests {
 		equal := c.x.Equal(c.y)
@@ -64,6 +69,271 @@ func TestGraphIdEqual(t *testing.T) {
 	}
 }
 
+func TestGraphIdLeadingZeros(t *testing.T) {
+	want := mustNewGraphId("3.1")
+
+	var scanned, unmarshaled GraphId
+	err := scanned.Scan([]byte("003.0001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = unmarshaled.UnmarshalJSON([]byte(`"03.1"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted, _ := AsGraphId("3.001")
+
+	for _, gid := range []GraphId{mustNewGraphId("3.01"), scanned, unmarshaled, converted} {
+		if gid != want {
+			t.Errorf("got %s, want %s", gid, want)
+		}
+	}
+
+	m := map[GraphId]int{want: 1}
+	if m[scanned] != 1 {
+		t.Errorf("got no map entry for %s", scanned)
+	}
+}
+
+func TestGraphIdAppendTo(t *testing.T) {
+	tests := []struct {
+		gid  GraphId
+		want string
+	}{
+		{mustNewGraphId("NULL"), "id = NULL"},
+		{mustNewGraphId("1.1"), "id = 1.1"},
+		{mustNewGraphId("65535.281474976710655"), "id = 65535.281474976710655"},
+	}
+	for _, c := range tests {
+		b := c.gid.AppendTo([]byte("id = "))
+		if s := string(b); s != c.want {
+			t.Errorf("got %q, want %q", s, c.want)
+		}
+	}
+}
+
+func TestAsGraphId(t *testing.T) {
+	var m map[string]interface{}
+	err := json.Unmarshal([]byte(`{"id": "3.1", "n": 3.1, "s": "go", "null": "NULL", "zero": "0.1"}`), &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key string
+		ok  bool
+	}{
+		{"id", true},
+		{"n", false},
+		{"s", false},
+		{"null", false},
+		{"zero", false},
+		{"missing", false},
+	}
+	for _, c := range tests {
+		gid, ok := AsGraphId(m[c.key])
+		if ok != c.ok {
+			t.Errorf("got %t for %v, want %t", ok, m[c.key], c.ok)
+		} else if ok && !gid.Equal(mustNewGraphId("3.1")) {
+			t.Errorf("got %s, want 3.1", gid)
+		}
+	}
+
+	gid, ok := AsGraphId(json.Number("65535.281474976710655"))
+	if !ok || gid.String() != "65535.281474976710655" {
+		t.Errorf("got %s, %t, want 65535.281474976710655, true", gid, ok)
+	}
+}
+
+func TestGraphIdKey(t *testing.T) {
+	tests := []struct {
+		gid GraphId
+		key uint64
+	}{
+		{mustNewGraphId("NULL"), 0},
+		{mustNewGraphId("1.1"), 1<<48 | 1},
+		{mustNewGraphId("3.2"), 3<<48 | 2},
+		{mustNewGraphId("65535.281474976710655"), 1<<64 - 1},
+	}
+	for _, c := range tests {
+		if key := c.gid.Key(); key != c.key {
+			t.Errorf("got %q.Key() == %#x, want %#x", c.gid, key, c.key)
+		}
+	}
+}
+
+func TestLabelIdRange(t *testing.T) {
+	tests := []struct {
+		labelId uint16
+		min     string
+		max     string
+	}{
+		{0, "NULL", "NULL"},
+		{1, "1.1", "1.281474976710655"},
+		{3, "3.1", "3.281474976710655"},
+		{65535, "65535.1", "65535.281474976710655"},
+	}
+	for _, c := range tests {
+		min, max := LabelIdRange(c.labelId)
+		if min.String() != c.min || max.String() != c.max {
+			t.Errorf("got %s and %s for %d, want %s and %s", min, max, c.labelId, c.min, c.max)
+		}
+		if c.labelId != 0 && (min.LabelId() != c.labelId || max.Key()-min.Key() != 1<<48-2) {
+			t.Errorf("got %s and %s, want the range of label %d", min, max, c.labelId)
+		}
+		if _, err := max.Next(); c.labelId != 0 && err == nil {
+			t.Errorf("error expected for %s.Next()", max)
+		}
+	}
+}
+
+func TestGraphIdJSON(t *testing.T) {
+	var s struct {
+		Id    GraphId
+		Ids   []GraphId
+		IdMap map[string]GraphId
+	}
+	b := []byte(`{"id": "3.1", "ids": ["3.2", null, 3.3], "idMap": {"a": "4.1"}}`)
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.Id.Equal(mustNewGraphId("3.1")) {
+		t.Errorf("got %s, want 3.1", s.Id)
+	}
+	if len(s.Ids) != 3 || !s.Ids[0].Equal(mustNewGraphId("3.2")) || s.Ids[1].Valid || !s.Ids[2].Equal(mustNewGraphId("3.3")) {
+		t.Errorf("got %v, want [3.2 NULL 3.3]", s.Ids)
+	}
+	if !s.IdMap["a"].Equal(mustNewGraphId("4.1")) {
+		t.Errorf("got %s, want 4.1", s.IdMap["a"])
+	}
+
+	out, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Id":"3.1","Ids":["3.2",null,"3.3"],"IdMap":{"a":"4.1"}}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
+
+func TestGraphIdJSONPacked(t *testing.T) {
+	tests := []struct {
+		gid string
+		key uint64
+	}{
+		{"3.1", 3<<48 | 1},
+		// above 2^53, which float64 cannot hold exactly
+		{"65535.281474976710655", 1<<64 - 1},
+		{"32768.3", 1<<63 | 3},
+	}
+	for _, c := range tests {
+		var gid GraphId
+		err := json.Unmarshal([]byte(strconv.FormatUint(c.key, 10)), &gid)
+		if err != nil {
+			t.Error(err)
+		} else if gid.String() != c.gid || gid.Key() != c.key {
+			t.Errorf("got %s (%d), want %s (%d)", gid, gid.Key(), c.gid, c.key)
+		}
+	}
+
+	for _, c := range []string{`1`, `18446744073709551616`, `-1`, `1e3`} {
+		var gid GraphId
+		err := json.Unmarshal([]byte(c), &gid)
+		if err == nil {
+			t.Errorf("error expected for %s", c)
+		}
+	}
+}
+
+func TestGraphIdJSONError(t *testing.T) {
+	tests := []string{`"0.1"`, `"x"`, `true`, `{}`, `"3.1`}
+	for _, c := range tests {
+		var gid GraphId
+		err := json.Unmarshal([]byte(c), &gid)
+		if err == nil {
+			t.Errorf("error expected for %s", c)
+		}
+	}
+}
+
+func TestGraphIdIsZero(t *testing.T) {
+	var gid GraphId
+	if !gid.IsZero() || !InvalidGraphId.IsZero() || !mustNewGraphId("NULL").IsZero() {
+		t.Error("got false, want true for NULL")
+	}
+	if mustNewGraphId("1.1").IsZero() {
+		t.Error("got true, want false for 1.1")
+	}
+	if gid != InvalidGraphId {
+		t.Errorf("got %q, want zero value", InvalidGraphId)
+	}
+	if _, err := NewGraphId("0.0"); err == nil {
+		t.Error(`error expected for "0.0"`)
+	}
+}
+
+func TestGraphIdPaddedString(t *testing.T) {
+	tests := []struct {
+		gid  string
+		want string
+	}{
+		{"3.1", "00003.000000000000001"},
+		{"65535.281474976710655", "65535.281474976710655"},
+		{"NULL", "NULL"},
+	}
+	for _, c := range tests {
+		if s := mustNewGraphId(c.gid).PaddedString(); s != c.want {
+			t.Errorf("got %s, want %s", s, c.want)
+		}
+	}
+
+	// lexical order is numeric order
+	ids := []string{"3.2", "3.10", "10.1", "3.1", "2.100"}
+	ps := make([]string, len(ids))
+	for i, id := range ids {
+		ps[i] = mustNewGraphId(id).PaddedString()
+	}
+	sort.Strings(ps)
+	sort.Slice(ids, func(i, j int) bool {
+		return mustNewGraphId(ids[i]).Key() < mustNewGraphId(ids[j]).Key()
+	})
+	for i, id := range ids {
+		if want := mustNewGraphId(id).PaddedString(); ps[i] != want {
+			t.Errorf("got %s at %d, want %s", ps[i], i, want)
+		}
+	}
+}
+
+func TestGraphIdNext(t *testing.T) {
+	gid := mustNewGraphId("3.1")
+	for i := 2; i <= 11; i++ {
+		var err error
+		gid, err = gid.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("3.%d", i); gid.String() != want {
+			t.Errorf("got %s, want %s", gid, want)
+		}
+	}
+
+	gid, err := mustNewGraphId("65535.281474976710654").Next()
+	if err != nil {
+		t.Error(err)
+	} else if want := "65535.281474976710655"; gid.String() != want {
+		t.Errorf("got %s, want %s", gid, want)
+	}
+
+	for _, s := range []string{"NULL", "3.281474976710655"} {
+		_, err := mustNewGraphId(s).Next()
+		if err == nil {
+			t.Errorf("error expected for %s", s)
+		}
+	}
+}
+
 func TestGraphIdScanNil(t *testing.T) {
 	var gid GraphId
 	err := gid.Scan(nil)
@@ -92,13 +362,145 @@ func TestGraphIdScanZero(t *testing.T) {
 	}
 }
 
+func TestGraphIdScanRecord(t *testing.T) {
+	for _, s := range []string{"3.1", "(3.1)"} {
+		for _, src := range []interface{}{[]byte(s), s} {
+			var gid GraphId
+			err := gid.Scan(src)
+			if err != nil {
+				t.Error(err)
+			} else if gid.String() != "3.1" {
+				t.Errorf("got %s for %s, want 3.1", gid, s)
+			}
+
+			var k GraphIdKey
+			err = k.Scan(src)
+			if err != nil {
+				t.Error(err)
+			} else if k != 3<<48|1 {
+				t.Errorf("got %d for %s, want %d", k, s, 3<<48|1)
+			}
+		}
+	}
+
+	for _, s := range []string{"()", "(3.1", "3.1)", "((3.1))", "(3.1,3.2)"} {
+		for _, src := range []interface{}{[]byte(s), s} {
+			var gid GraphId
+			if err := gid.Scan(src); err == nil {
+				t.Errorf("error expected for %s", s)
+			}
+
+			var k GraphIdKey
+			if err := k.Scan(src); err == nil {
+				t.Errorf("error expected for %s", s)
+			}
+		}
+	}
+}
+
+func TestGraphIdParse(t *testing.T) {
+	tests := []struct {
+		s   string
+		key uint64
+	}{
+		{"1.1", 1<<48 | 1},
+		{"3.1", 3<<48 | 1},
+		{"65535.281474976710655", 1<<64 - 1},
+		{"00003.000000000000001", 3<<48 | 1},
+	}
+	for _, c := range tests {
+		key, err := parseGraphId(c.s)
+		if err != nil {
+			t.Error(err)
+		} else if key != c.key {
+			t.Errorf("got %d for %s, want %d", key, c.s, c.key)
+		}
+
+		var k GraphIdKey
+		err = k.Scan([]byte(c.s))
+		if err != nil {
+			t.Error(err)
+		} else if uint64(k) != c.key {
+			t.Errorf("got %d for %s, want %d", k, c.s, c.key)
+		}
+	}
+
+	bad := []string{
+		"", ".", "1.", ".1", "1", "1..1", "1.1.", "1.1 ", " 1.1", "-1.1", "1.-1", "+1.1", "a.1",
+		"0.1", "1.0", "65536.1", "1.281474976710656", "18446744073709551617.1",
+		"1.99999999999999999999999",
+	}
+	for _, s := range bad {
+		if _, err := parseGraphId(s); err == nil {
+			t.Errorf("error expected for %q", s)
+		}
+		var k GraphIdKey
+		if err := k.Scan([]byte(s)); err == nil {
+			t.Errorf("error expected for %q", s)
+		}
+	}
+
+	var k GraphIdKey = 5
+	if err := k.Scan(nil); err != nil || k != 0 {
+		t.Errorf("got %d, %v, want 0", k, err)
+	}
+	if s := GraphIdKey(3<<48 | 1).GraphId().String(); s != "3.1" {
+		t.Errorf("got %s, want 3.1", s)
+	}
+	if gid := GraphIdKey(3 << 48).GraphId(); gid.Valid {
+		t.Errorf("got %s, want NULL", gid)
+	}
+
+	if v, err := GraphIdKey(3<<48 | 1).Value(); err != nil || string(v.([]byte)) != "3.1" {
+		t.Errorf("got %v, %v, want 3.1", v, err)
+	}
+	if v, err := GraphIdKey(0).Value(); err != nil || v != nil {
+		t.Errorf("got %v, %v, want nil", v, err)
+	}
+}
+
+func TestGraphIdScanAllocs(t *testing.T) {
+	for _, src := range []interface{}{[]byte("65535.281474976710655"), "(65535.281474976710655)"} {
+		var k GraphIdKey
+		if n := testing.AllocsPerRun(100, func() { _ = k.Scan(src) }); n != 0 {
+			t.Errorf("got %v allocs for %T, want 0", n, src)
+		}
+	}
+
+	// GraphId copies the text only
+	var src interface{} = []byte("65535.281474976710655")
+	var gid GraphId
+	if n := testing.AllocsPerRun(100, func() { _ = gid.Scan(src) }); n != 1 {
+		t.Errorf("got %v allocs, want 1", n)
+	}
+}
+
+func BenchmarkGraphIdScan(b *testing.B) {
+	var src interface{} = []byte("3.281474976710655")
+	b.Run("GraphId", func(b *testing.B) {
+		b.ReportAllocs()
+		var gid GraphId
+		for i := 0; i < b.N; i++ {
+			_ = gid.Scan(src)
+		}
+	})
+	b.Run("GraphIdKey", func(b *testing.B) {
+		b.ReportAllocs()
+		var k GraphIdKey
+		for i := 0; i < b.N; i++ {
+			_ = k.Scan(src)
+		}
+	})
+}
+
 func TestGraphIdScanDuplicate(t *testing.T) {
 	src := []byte("1.1")
 
 	var gid GraphId
 	_ = gid.Scan(src)
 
-	if &gid.b[0] == &src[0] {
+	src[0] = '2'
+	if gid.String() != "1.1" {
 		t.Error("GraphId references underlying array")
 	}
 }
@@ -162,6 +564,33 @@ func TestGraphIdArrayScan(t *testing.T) {
 	}
 }
 
+func TestGraphIdArrayScanBadBraces(t *testing.T) {
+	for _, s := range []string{"{", "}", "1.1", "{1.1", "1.1}", "[1.1]"} {
+		var gids []GraphId
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("panic for %q: %v", s, r)
+				}
+			}()
+			if err := Array(&gids).Scan([]byte(s)); err == nil {
+				t.Errorf("error expected for %q", s)
+			}
+		}()
+	}
+}
+
+func TestGraphIdArrayScanNull(t *testing.T) {
+	var gids []GraphId
+	err := Array(&gids).Scan([]byte("{null,1.1,NULL}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gids) != 3 || gids[0].Valid || !gids[1].Valid || gids[2].Valid {
+		t.Errorf("got %v, want [NULL 1.1 NULL]", gids)
+	}
+}
+
 func TestGraphIdArrayValue(t *testing.T) {
 	for _, c := range graphIdArrayTests {
 		val, err := Array(c.gids).Value()
@@ -232,6 +661,14 @@ func TestServerGraphId(t *testing.T) {
 		t.Error(err)
 	}
 
+	clause, args := IdInClause("n", []GraphId{gid})
+	err = db.QueryRow(`MATCH (n:gid) WHERE `+clause+` RETURN count(*)`, args...).Scan(&cnt)
+	if err != nil {
+		t.Error(err)
+	} else if cnt != 1 {
+		t.Errorf("got %d, want %d", cnt, 1)
+	}
+
 	err = db.QueryRow(`SELECT NULL::_graphid`).Scan(Array(&gidsOut))
 	if err != nil {
 		t.Error(err)
@@ -18,6 +18,7 @@ package ag
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -40,36 +41,117 @@ type PathSaver interface {
 
 // ScanPath reads a path from src and stores the result by calling SavePath.
 //
-// An error will be returned if the type of src is not []byte, or src is
+// src may be any type that ScanEntity accepts. The path may be in the text
+// form of graphpath or in JSON, such as a path in the result of to_jsonb or
+// in a Cypher map, which is an array of the JSON objects of vertices and
+// edges (see AgValue). The form is detected automatically.
+//
+// opts apply to reading the elements, such as SkipEndpoints, and the hooks,
+// OnError and OnEntity. Since SavePath stores the elements, the options for
+// storing them, such as Strict, must be given to ScanEntity in SavePath.
+//
+// An error will be returned if the type of src is not one of them, or src is
 // invalid.
-func ScanPath(src interface{}, saver PathSaver) error {
+func ScanPath(src interface{}, saver PathSaver, opts ...ScanOption) error {
+	return tracedScanPath(src, saver, newScanOptions(opts))
+}
+
+func tracedScanPath(src interface{}, saver PathSaver, o scanOptions) error {
+	return o.traced("ag.ScanPath", src, saver, func() error {
+		return scanPath(src, saver, o)
+	})
+}
+
+func scanPath(src interface{}, saver PathSaver, o scanOptions) error {
 	if src == nil {
 		return saver.SavePath(false, nil)
 	}
 
-	b, ok := src.([]byte)
+	b, ok := textSource(src)
 	if !ok {
-		return fmt.Errorf("invalid source for graphpath: %T", src)
+		return o.reportError(fmt.Errorf("invalid source for graphpath: %T", src), nil)
 	}
+	o.countBytes(len(b))
 
 	n := len(b)
 	if n < 1 {
-		return fmt.Errorf("invalid source for graphpath: %v", b)
+		return o.reportError(fmt.Errorf("invalid source for graphpath: %v", b), b)
 	}
 
-	advance, ds, err := readPath(b)
-	if err != nil {
-		return err
+	var ds []interface{}
+	if isJSONPath(b) {
+		var err error
+		ds, err = readJSONPath(b, o)
+		if err != nil {
+			return o.reportError(err, b)
+		}
+	} else {
+		advance, rds, err := readPath(b, o)
+		if err != nil {
+			return o.reportError(err, b)
+		}
+		if advance != n {
+			return o.reportError(fmt.Errorf("bad graphpath representation: %s", b), b)
+		}
+		ds = rds
+	}
+
+	for _, d := range ds {
+		if d != nil {
+			d := d.(*entityData)
+			o.reportEntity(d)
+			d.skipProperties = o.skipsProperties(d)
+		}
 	}
-	if advance != n {
-		return fmt.Errorf("bad graphpath representation: %s", b)
+
+	return o.reportError(saver.SavePath(true, ds), b)
+}
+
+// isJSONPath reports whether b is a path in JSON, whose first element is an
+// object, rather than the text form of graphpath.
+func isJSONPath(b []byte) bool {
+	b = bytes.TrimLeft(b, " \t\r\n")
+	if len(b) < 1 || b[0] != '[' {
+		return false
+	}
+	b = bytes.TrimLeft(b[1:], " \t\r\n")
+	return len(b) > 0 && b[0] == '{'
+}
+
+func readJSONPath(b []byte, o scanOptions) ([]interface{}, error) {
+	var es []*jsonEntity
+	err := json.Unmarshal(b, &es)
+	if err != nil {
+		return nil, errors.New("bad graphpath representation: " + err.Error())
 	}
 
-	return saver.SavePath(true, ds)
+	ds := make([]interface{}, len(es))
+	for i, e := range es {
+		if e == nil {
+			continue
+		}
+		d, ok, err := e.entityData(o)
+		if !ok {
+			return nil, fmt.Errorf("invalid path element %d: not a vertex or an edge", i)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid path element %d: %v", i, err)
+		}
+
+		_, isEdge := d.core.(EdgeCore)
+		if isEdge != (i%2 == 1) {
+			return nil, fmt.Errorf("invalid path element %d: vertices and edges must alternate", i)
+		}
+		ds[i] = d
+	}
+	if len(es)%2 == 0 && len(es) > 0 {
+		return nil, errors.New("bad graphpath representation: path ends with an edge")
+	}
+	return ds, nil
 }
 
-func readPath(b []byte) (advance int, ds []interface{}, err error) {
-	if bytes.HasPrefix(b, nullElementValue) {
+func readPath(b []byte, o scanOptions) (advance int, ds []interface{}, err error) {
+	if hasNullPrefix(b) {
 		advance = len(nullElementValue)
 		return
 	}
@@ -81,14 +163,18 @@ func readPath(b []byte) (advance int, ds []interface{}, err error) {
 	advance = 1
 
 	read, readNext := readVertexElement, readEdgeElement
-	for b[advance] != byte(']') {
+	for advance < len(b) && b[advance] != byte(']') {
 		if len(ds) > 0 {
+			if b[advance] != byte(',') {
+				err = fmt.Errorf("bad graphpath representation: %s", b)
+				return
+			}
 			// remove comma
 			advance++
 		}
 
-		n, d, r := read(b[advance:])
-		if err != nil {
+		n, d, r := read(b[advance:], o)
+		if r != nil {
 			err = errors.New("invalid path element: " + r.Error())
 			return
 		}
@@ -102,6 +188,14 @@ func readPath(b []byte) (advance int, ds []interface{}, err error) {
 
 		read, readNext = readNext, read
 	}
+	if advance >= len(b) {
+		err = fmt.Errorf("unexpected end of graphpath: %s", b)
+		return
+	}
+	if len(ds)%2 == 0 && len(ds) > 0 {
+		err = fmt.Errorf("bad graphpath representation: %s", b)
+		return
+	}
 	advance++
 
 	return
@@ -128,18 +222,257 @@ func (p BasicPath) String() string {
 
 	ne := len(p.Edges)
 
-	s := make([]string, 0, nv+ne)
+	// Guess the length of each element to reduce reallocations.
+	var b strings.Builder
+	b.Grow(2 + (nv+ne)*pathElementSizeHint)
+
+	b.WriteByte('[')
 	for i := 0; i < ne; i++ {
-		s = append(s, p.Vertices[i].String(), p.Edges[i].String())
+		p.Vertices[i].writeString(&b)
+		b.WriteByte(',')
+		p.Edges[i].writeString(&b)
+		b.WriteByte(',')
+	}
+	p.Vertices[nv-1].writeString(&b)
+	b.WriteByte(']')
+
+	return b.String()
+}
+
+const pathElementSizeHint = 48
+
+// Equal reports whether p and other have equal vertices and edges in the same
+// order. See (BasicVertex).Equal and (BasicEdge).Equal. Two NULL paths are
+// equal.
+func (p BasicPath) Equal(other BasicPath) bool {
+	if !p.Valid || !other.Valid {
+		return p.Valid == other.Valid
+	}
+	if len(p.Vertices) != len(other.Vertices) || len(p.Edges) != len(other.Edges) {
+		return false
+	}
+	for i := range p.Vertices {
+		if !p.Vertices[i].Equal(other.Vertices[i]) {
+			return false
+		}
+	}
+	for i := range p.Edges {
+		if !p.Edges[i].Equal(other.Edges[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Found reports whether p is a path rather than NULL. It is the same as Valid,
+// but reads better for the result of shortestpath(), which is NULL if there is
+// no route:
+//
+//	var p ag.BasicPath
+//	err := db.QueryRow(`MATCH p = shortestpath((a)-[*]->(b)) ... RETURN p`).Scan(&p)
+//	if err != nil {
+//		return err
+//	}
+//	if !p.Found() {
+//		// no route
+//	}
+//
+// A found path always has at least one vertex; "[]", which has none, is not
+// what shortestpath() returns.
+func (p BasicPath) Found() bool {
+	return p.Valid
+}
+
+// TotalWeight returns the sum of the numeric property prop of all the edges in
+// p. It returns 0 for a path without edges.
+//
+// An error will be returned if any edge does not have prop or its value is not
+// a number.
+func (p BasicPath) TotalWeight(prop string) (float64, error) {
+	var sum float64
+	for _, e := range p.Edges {
+		v, ok := e.Properties[prop]
+		if !ok {
+			return 0, fmt.Errorf("edge %s has no property %q", e.Id, prop)
+		}
+
+		switch v := v.(type) {
+		case float64:
+			sum += v
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return 0, fmt.Errorf("invalid property %q of edge %s: %v", prop, e.Id, err)
+			}
+			sum += f
+		default:
+			return 0, fmt.Errorf("property %q of edge %s is not a number: %T", prop, e.Id, v)
+		}
+	}
+	return sum, nil
+}
+
+// VertexIds returns the IDs of the vertices in p in order. NULL vertices are
+// skipped. It returns nil if p is NULL.
+func (p BasicPath) VertexIds() []GraphId {
+	if !p.Valid {
+		return nil
+	}
+
+	ids := make([]GraphId, 0, len(p.Vertices))
+	for _, v := range p.Vertices {
+		if v.Valid {
+			ids = append(ids, v.Id)
+		}
+	}
+	return ids
+}
+
+// HasCycle reports whether p visits any vertex more than once. It takes O(n)
+// time and space for a path of n vertices. NULL vertices are ignored.
+func (p BasicPath) HasCycle() bool {
+	ids := p.VertexIds()
+	seen := make(map[GraphId]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			return true
+		}
+		seen[id] = struct{}{}
+	}
+	return false
+}
+
+// IsSimple reports whether p visits every vertex at most once. It is the
+// negation of HasCycle.
+func (p BasicPath) IsSimple() bool {
+	return !p.HasCycle()
+}
+
+// Intersects reports whether p and other share any vertex. It returns false
+// if either of them is NULL.
+func (p BasicPath) Intersects(other BasicPath) bool {
+	return len(p.sharedVertices(other, 1)) > 0
+}
+
+// SharedVertices returns the IDs of the vertices that are in both p and other,
+// in the order they appear in p without duplicates. It returns nil if there
+// are none or either of them is NULL.
+func (p BasicPath) SharedVertices(other BasicPath) []GraphId {
+	return p.sharedVertices(other, -1)
+}
+
+// sharedVertices returns at most n shared vertices. If n < 0, it returns all.
+func (p BasicPath) sharedVertices(other BasicPath, n int) []GraphId {
+	if !p.Valid || !other.Valid {
+		return nil
+	}
+
+	ids := make(map[GraphId]bool)
+	for _, id := range other.VertexIds() {
+		ids[id] = true
 	}
-	s = append(s, p.Vertices[nv-1].String())
 
-	return fmt.Sprintf("[%s]", strings.Join(s, ","))
+	var shared []GraphId
+	for _, id := range p.VertexIds() {
+		if n >= 0 && len(shared) >= n {
+			break
+		}
+		if ids[id] {
+			shared = append(shared, id)
+			// avoid duplicates
+			ids[id] = false
+		}
+	}
+	return shared
 }
 
-// SavePath implements PathSaver interface.
+// PathToTriples returns the edges of p as triples in the order they appear in
+// p. See (BasicEdge).AsTriple.
+func PathToTriples(p BasicPath) []Triple {
+	ts := make([]Triple, len(p.Edges))
+	for i, e := range p.Edges {
+		ts[i].Subject, ts[i].Predicate, ts[i].Object = e.AsTriple()
+	}
+	return ts
+}
+
+// SplitAt splits p at the vertex whose ID is id. The vertex is the last
+// vertex of before and the first vertex of after. If p visits the vertex more
+// than once, p is split at the first visit.
+//
+// ok is false if the vertex is not an intermediate vertex of p, which means it
+// is not in p or it is the first or the last vertex of p.
+//
+// before and after share the underlying arrays with p.
+func (p BasicPath) SplitAt(id GraphId) (before, after BasicPath, ok bool) {
+	if len(p.Vertices) != len(p.Edges)+1 {
+		return
+	}
+
+	for i := 1; i < len(p.Vertices)-1; i++ {
+		if !p.Vertices[i].Id.Equal(id) {
+			continue
+		}
+
+		before = BasicPath{true, p.Vertices[: i+1 : i+1], p.Edges[:i:i]}
+		after = BasicPath{true, p.Vertices[i:], p.Edges[i:]}
+		return before, after, true
+	}
+	return
+}
+
+// Slice returns the sub-path of p that covers the hops from startHop up to but
+// not including endHop, where hop i is p.Edges[i] between p.Vertices[i] and
+// p.Vertices[i+1]. If startHop equals endHop, the sub-path has the single
+// vertex p.Vertices[startHop].
+//
+// An error will be returned if p is NULL or the hops are out of range, which
+// means they are not 0 <= startHop <= endHop <= len(p.Edges).
+//
+// The sub-path shares the underlying arrays with p.
+func (p BasicPath) Slice(startHop, endHop int) (BasicPath, error) {
+	if !p.Valid {
+		return BasicPath{}, errors.New("cannot slice NULL path")
+	}
+	ne := len(p.Edges)
+	if len(p.Vertices) != ne+1 {
+		return BasicPath{}, fmt.Errorf("invalid path: %d vertices and %d edges", len(p.Vertices), ne)
+	}
+	if startHop < 0 || startHop > endHop || endHop > ne {
+		return BasicPath{}, fmt.Errorf("hops [%d, %d) out of range for path of %d hops", startHop, endHop, ne)
+	}
+
+	return BasicPath{true, p.Vertices[startHop : endHop+1 : endHop+1], p.Edges[startHop:endHop:endHop]}, nil
+}
+
+// DirectedHop is a hop of a path from the vertex of ID From to the vertex of
+// ID To through Edge. Forward is true if Edge points along the traversal,
+// which means Edge.Start is From, and false if it points against it.
+type DirectedHop struct {
+	From    GraphId
+	Edge    BasicEdge
+	To      GraphId
+	Forward bool
+}
+
+// DirectedHops returns the hops of p in the order they are traversed.
+func (p BasicPath) DirectedHops() []DirectedHop {
+	if len(p.Vertices) < len(p.Edges)+1 {
+		return nil
+	}
+
+	hs := make([]DirectedHop, len(p.Edges))
+	for i, e := range p.Edges {
+		from, to := p.Vertices[i].Id, p.Vertices[i+1].Id
+		hs[i] = DirectedHop{from, e, to, e.Start.Equal(from)}
+	}
+	return hs
+}
+
+// SavePath implements PathSaver interface. The vertices and the edges of p
+// from a previous scan are dropped, even if the path is NULL.
 func (p *BasicPath) SavePath(valid bool, ds []interface{}) error {
-	p.Valid = valid
+	p.Valid, p.Vertices, p.Edges = valid, nil, nil
 	if !valid {
 		return nil
 	}
@@ -148,6 +481,9 @@ func (p *BasicPath) SavePath(valid bool, ds []interface{}) error {
 	if n < 1 {
 		return nil
 	}
+	if n%2 == 0 {
+		return errors.New("path must end with a vertex")
+	}
 
 	ne := n / 2
 	p.Vertices = make([]BasicVertex, ne+1)
@@ -168,9 +504,9 @@ func (p *BasicPath) SavePath(valid bool, ds []interface{}) error {
 
 		i, j = i+1, j+2
 	}
-	p.Vertices[i].Scan(ds[j])
 
-	return nil
+	// ds[j] is nil for a NULL vertex, which is stored as an invalid vertex.
+	return p.Vertices[i].Scan(ds[j])
 }
 
 // Scan implements the database/sql Scanner interface. It calls ScanPath.
@@ -16,7 +16,11 @@ limitations under the License.
 
 package ag
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestBasicPathScanNil(t *testing.T) {
 	var p BasicPath
@@ -28,6 +32,34 @@ func TestBasicPathScanNil(t *testing.T) {
 	}
 }
 
+func TestBasicPathFound(t *testing.T) {
+	p := makeTestPath(2)
+	if !p.Found() {
+		t.Errorf("got false for %s, want true", p)
+	}
+
+	// scanning NULL into a path that has been used drops the old result
+	err := p.Scan(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Found() || p.Vertices != nil || p.Edges != nil {
+		t.Errorf("got %t with %v and %v, want false with nil", p.Found(), p.Vertices, p.Edges)
+	}
+	if s := p.String(); s != "NULL" {
+		t.Errorf("got %s, want NULL", s)
+	}
+
+	p = makeTestPath(2)
+	err = p.Scan([]byte(`[v[3.1]{}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Found() || len(p.Vertices) != 1 || p.Edges != nil {
+		t.Errorf("got %s, want [v[3.1]{}]", p)
+	}
+}
+
 func TestBasicPathScanType(t *testing.T) {
 	src := 0
 	var p BasicPath
@@ -54,6 +86,8 @@ func TestBasicPathScan(t *testing.T) {
 	}{
 		{[]byte("[]"), 0, 0},
 		{[]byte(`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{},NULL,NULL]`), 3, 2},
+		{[]byte(`[v[3.1]{},null,v[3.2]{}]`), 2, 1},
+		{[]byte(`[NULLv[3.1]{},NULLe[4.1][3.1,3.2]{},NULLv[3.2]{}]`), 2, 1},
 	}
 	for _, c := range tests {
 		var p BasicPath
@@ -72,6 +106,532 @@ func TestBasicPathScan(t *testing.T) {
 	}
 }
 
+func TestBasicPathScanString(t *testing.T) {
+	src := `[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`
+	var p BasicPath
+	err := p.Scan(src)
+	if err != nil {
+		t.Error(err)
+	} else if !p.Valid {
+		t.Errorf("got NULL, want Valid %T", p)
+	} else if nv, ne := len(p.Vertices), len(p.Edges); nv != 2 || ne != 1 {
+		t.Errorf("got %d vertices and %d edges, want 2 and 1", nv, ne)
+	}
+}
+
+func TestBasicPathTotalWeight(t *testing.T) {
+	tests := []struct {
+		b   []byte
+		sum float64
+		err bool
+	}{
+		{[]byte(`[v[3.1]{}]`), 0, false},
+		{[]byte(`[v[3.1]{},e[4.1][3.1,3.2]{"w": 1.5},v[3.2]{},e[4.2][3.2,3.3]{"w": 2},v[3.3]{}]`), 3.5, false},
+		{[]byte(`[v[3.1]{},e[4.1][3.1,3.2]{"w": 1.5},v[3.2]{},e[4.2][3.2,3.3]{},v[3.3]{}]`), 0, true},
+		{[]byte(`[v[3.1]{},e[4.1][3.1,3.2]{"w": "1.5"},v[3.2]{}]`), 0, true},
+	}
+	for _, c := range tests {
+		var p BasicPath
+		err := p.Scan(c.b)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		sum, err := p.TotalWeight("w")
+		if c.err {
+			if err == nil {
+				t.Errorf("error expected for %s", c.b)
+			}
+		} else if err != nil {
+			t.Error(err)
+		} else if sum != c.sum {
+			t.Errorf("got %g, want %g", sum, c.sum)
+		}
+	}
+}
+
+func TestPathToTriples(t *testing.T) {
+	var p BasicPath
+	err := p.Scan([]byte(`[v[3.1]{},knows[4.1][3.1,3.2]{},v[3.2]{},likes[5.1][3.3,3.2]{},v[3.3]{}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Triple{
+		{mustNewGraphId("3.1"), "knows", mustNewGraphId("3.2")},
+		{mustNewGraphId("3.3"), "likes", mustNewGraphId("3.2")},
+	}
+	ts := PathToTriples(p)
+	if len(ts) != len(want) {
+		t.Fatalf("got %d triples, want %d", len(ts), len(want))
+	}
+	for i, tr := range ts {
+		if tr != want[i] {
+			t.Errorf("got %v, want %v", tr, want[i])
+		}
+	}
+}
+
+func TestBasicPathDirectedHops(t *testing.T) {
+	var p BasicPath
+	err := p.Scan([]byte(`[v[3.1]{},knows[4.1][3.1,3.2]{},v[3.2]{},likes[5.1][3.3,3.2]{},v[3.3]{}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		from    string
+		edge    string
+		to      string
+		forward bool
+	}{
+		{"3.1", "4.1", "3.2", true},
+		{"3.2", "5.1", "3.3", false},
+	}
+	hs := p.DirectedHops()
+	if len(hs) != len(want) {
+		t.Fatalf("got %d hops, want %d", len(hs), len(want))
+	}
+	for i, h := range hs {
+		w := want[i]
+		if h.From.String() != w.from || h.Edge.Id.String() != w.edge || h.To.String() != w.to || h.Forward != w.forward {
+			t.Errorf("got %s-%s->%s %t, want %s-%s->%s %t", h.From, h.Edge.Id, h.To, h.Forward, w.from, w.edge, w.to, w.forward)
+		}
+	}
+
+	if hs := (BasicPath{}).DirectedHops(); len(hs) != 0 {
+		t.Errorf("got %d hops, want 0", len(hs))
+	}
+}
+
+func TestBasicPathSplitAt(t *testing.T) {
+	p := makeTestPath(3)
+
+	before, after, ok := p.SplitAt(mustNewGraphId("3.2"))
+	if !ok {
+		t.Fatal("got false, want true")
+	}
+	if want := `[v[3.1]{"name":"v1"},e[4.1][3.1,3.2]{"weight":1},v[3.2]{"name":"v2"}]`; before.String() != want {
+		t.Errorf("got %s, want %s", before, want)
+	}
+	if want := `[v[3.2]{"name":"v2"},e[4.2][3.2,3.3]{"weight":2},v[3.3]{"name":"v3"},e[4.3][3.3,3.4]{"weight":3},v[3.4]{"name":"v4"}]`; after.String() != want {
+		t.Errorf("got %s, want %s", after, want)
+	}
+
+	for _, id := range []string{"3.1", "3.4", "3.9", "NULL"} {
+		_, _, ok := p.SplitAt(mustNewGraphId(id))
+		if ok {
+			t.Errorf("got true for %s, want false", id)
+		}
+	}
+}
+
+func TestBasicPathSlice(t *testing.T) {
+	p := makeTestPath(3)
+
+	tests := []struct {
+		start int
+		end   int
+		want  string
+	}{
+		{0, 3, p.String()},
+		{1, 2, `[v[3.2]{"name":"v2"},e[4.2][3.2,3.3]{"weight":2},v[3.3]{"name":"v3"}]`},
+		{0, 1, `[v[3.1]{"name":"v1"},e[4.1][3.1,3.2]{"weight":1},v[3.2]{"name":"v2"}]`},
+		{2, 2, `[v[3.3]{"name":"v3"}]`},
+		{3, 3, `[v[3.4]{"name":"v4"}]`},
+	}
+	for _, c := range tests {
+		s, err := p.Slice(c.start, c.end)
+		if err != nil {
+			t.Error(err)
+		} else if got := s.String(); got != c.want {
+			t.Errorf("got %s for [%d, %d), want %s", got, c.start, c.end, c.want)
+		}
+	}
+
+	for _, r := range [][2]int{{-1, 1}, {2, 1}, {0, 4}, {4, 4}} {
+		_, err := p.Slice(r[0], r[1])
+		if err == nil {
+			t.Errorf("error expected for [%d, %d)", r[0], r[1])
+		}
+	}
+
+	_, err := BasicPath{}.Slice(0, 0)
+	if err == nil {
+		t.Error("error expected for NULL path")
+	}
+}
+
+func TestBasicPathHasCycle(t *testing.T) {
+	tests := []struct {
+		p     string
+		cycle bool
+	}{
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{},e[4.2][3.2,3.3]{},v[3.3]{}]`, false},
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{},e[4.2][3.2,3.1]{},v[3.1]{}]`, true},
+		{`[v[3.1]{},e[4.1][3.1,3.1]{},v[3.1]{}]`, true},
+		{`[v[3.1]{}]`, false},
+		{`[]`, false},
+	}
+	for _, c := range tests {
+		var p BasicPath
+		err := p.Scan([]byte(c.p))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got := p.HasCycle(); got != c.cycle {
+			t.Errorf("got HasCycle() == %t for %s, want %t", got, c.p, c.cycle)
+		}
+		if got := p.IsSimple(); got == c.cycle {
+			t.Errorf("got IsSimple() == %t for %s, want %t", got, c.p, !c.cycle)
+		}
+	}
+}
+
+func TestScanPathSkipProperties(t *testing.T) {
+	src := []byte(makeTestPath(2).String())
+
+	tests := []struct {
+		opt      ScanOption
+		vertices bool
+		edges    bool
+	}{
+		{SkipVertexProperties(), false, true},
+		{SkipEdgeProperties(), true, false},
+		{SkipProperties(), false, false},
+	}
+	for _, c := range tests {
+		var p BasicPath
+		err := ScanPath(src, &p, c.opt)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !p.Vertices[1].Id.Equal(mustNewGraphId("3.2")) || p.Edges[1].Label != "e" {
+			t.Errorf("got %s, want the cores of %s", p, src)
+		}
+		for _, v := range p.Vertices {
+			if got := v.Properties != nil; got != c.vertices {
+				t.Errorf("got vertex properties %v, want them %t", v.Properties, c.vertices)
+			}
+		}
+		for _, e := range p.Edges {
+			if got := e.Properties != nil; got != c.edges {
+				t.Errorf("got edge properties %v, want them %t", e.Properties, c.edges)
+			}
+		}
+	}
+}
+
+func TestBasicPathEqual(t *testing.T) {
+	p := makeTestPath(2)
+	tests := []struct {
+		a     string
+		b     string
+		equal bool
+	}{
+		{p.String(), p.String(), true},
+		{`[v[3.1]{"a": 1, "b": 2}]`, `[v[3.1]{"b": 2, "a": 1}]`, true},
+		{`[v[3.1]{}]`, `[v[3.1]{"a": 1}]`, false},
+		{`[v[3.1]{}]`, `[w[3.1]{}]`, false},
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`, `[v[3.1]{},e[4.1][3.1,3.2]{"w": 1},v[3.2]{}]`, false},
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`, `[v[3.1]{},e[4.2][3.1,3.2]{},v[3.2]{}]`, false},
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`, `[v[3.1]{},e[4.1][3.2,3.1]{},v[3.2]{}]`, false},
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`, `[v[3.1]{}]`, false},
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},NULL]`, `[v[3.1]{},e[4.1][3.1,3.2]{},NULL]`, true},
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},NULL]`, `[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`, false},
+	}
+	for _, c := range tests {
+		var a, b BasicPath
+		if err := a.Scan([]byte(c.a)); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Scan([]byte(c.b)); err != nil {
+			t.Fatal(err)
+		}
+		if got := a.Equal(b); got != c.equal {
+			t.Errorf("got %t for %s and %s, want %t", got, c.a, c.b, c.equal)
+		}
+		if got := b.Equal(a); got != c.equal {
+			t.Errorf("got %t for %s and %s, want %t", got, c.b, c.a, c.equal)
+		}
+	}
+
+	if !(BasicPath{}).Equal(BasicPath{}) {
+		t.Error("got false for NULL paths, want true")
+	}
+	if (BasicPath{}).Equal(p) || p.Equal(BasicPath{}) {
+		t.Errorf("got true for NULL and %s, want false", p)
+	}
+}
+
+func makeTestPath(ne int) BasicPath {
+	var b strings.Builder
+	b.WriteString(`[v[3.1]{"name": "v1"}`)
+	for i := 1; i <= ne; i++ {
+		fmt.Fprintf(&b, `,e[4.%d][3.%d,3.%d]{"weight": %d},v[3.%d]{"name": "v%d"}`, i, i, i+1, i, i+1, i+1)
+	}
+	b.WriteString("]")
+
+	var p BasicPath
+	err := p.Scan([]byte(b.String()))
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestReadPathAlternation(t *testing.T) {
+	b := []byte(`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{},e[4.2][3.2,3.3]{},v[3.3]{}]`)
+	advance, ds, err := readPath(b, scanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if advance != len(b) {
+		t.Errorf("got advance == %d, want %d", advance, len(b))
+	}
+	if len(ds) != 5 {
+		t.Fatalf("got %d elements, want 5", len(ds))
+	}
+	for i, d := range ds {
+		core := d.(*entityData).core
+		var ok bool
+		if i%2 == 0 {
+			_, ok = core.(VertexCore)
+		} else {
+			_, ok = core.(EdgeCore)
+		}
+		if !ok {
+			t.Errorf("got %T for element %d", core, i)
+		}
+	}
+}
+
+func TestBasicPathScanTruncated(t *testing.T) {
+	checkTruncated(t, `[v[3.1]{},e[4.1][3.1,3.2]{"a": "]"},v[3.2]{}]`, func(b []byte) error {
+		var p BasicPath
+		return p.Scan(b)
+	})
+}
+
+func TestBasicPathScanJSON(t *testing.T) {
+	native := `[v[3.1]{"name": "a"},e[4.1][3.1,3.2]{"w": 1},v[3.2]{}]`
+	jsonPath := ` [ {"label": "v", "id": "3.1", "properties": {"name": "a"}},
+		{"label": "e", "id": "4.1", "start": "3.1", "end": "3.2", "properties": {"w": 1}},
+		{"label": "v", "id": "3.2", "properties": {}}]`
+
+	var p, q BasicPath
+	err := p.Scan(native)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = q.Scan([]byte(jsonPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != q.String() {
+		t.Errorf("got %s, want %s", q, p)
+	}
+
+	err = q.Scan(`[{"label": "v", "id": "3.1", "properties": {}}, null, {"label": "v", "id": "3.2", "properties": {}}]`)
+	if err != nil {
+		t.Error(err)
+	} else if len(q.Edges) != 1 || q.Edges[0].Valid {
+		t.Errorf("got %s, want a NULL edge", q)
+	}
+
+	tests := []string{
+		`[{"label": "v", "id": "3.1", "properties": {}}, {"label": "v", "id": "3.2", "properties": {}}]`,
+		`[{"label": "e", "id": "4.1", "start": "3.1", "end": "3.2", "properties": {}}]`,
+		`[{"label": "v", "id": "3.1", "properties": {}}, {"label": "e", "id": "4.1", "start": "3.1", "properties": {}}, {"label": "v", "id": "3.2", "properties": {}}]`,
+		`[{"label": "v", "id": "3.1", "properties": {}}, {"label": "e", "id": "4.1", "start": "3.1", "end": "3.2", "properties": {}}]`,
+		`[{"label": "v", "id": "3.1", "properties": {}}, null]`,
+		`[{"label": "v", "id": "3.1"}]`,
+		`[{"id": "3.1", "properties": {}}]`,
+		`[{"label": "v", "id": "3.1", "properties": {}}`,
+	}
+	for _, c := range tests {
+		if err := q.Scan(c); err == nil {
+			t.Errorf("error expected for %s", c)
+		}
+	}
+}
+
+func TestBasicPathScanWrongOrder(t *testing.T) {
+	tests := []string{
+		`[e[4.1][3.1,3.2]{},v[3.2]{}]`,
+		`[v[3.1]{},v[3.2]{}]`,
+		`[v[3.1]{},e[4.1][3.1,3.2]{},e[4.2][3.2,3.3]{}]`,
+		`[v[3.1]{},e[4.1][3.1,3.2]{}]`,
+		`[v[3.1]{},NULL]`,
+	}
+	for _, c := range tests {
+		var p BasicPath
+		err := p.Scan(c)
+		if err == nil {
+			t.Errorf("error expected for %s", c)
+		}
+	}
+}
+
+func TestBasicPathScanNullElements(t *testing.T) {
+	tests := []struct {
+		b     string
+		nullV []bool
+		nullE []bool
+	}{
+		{`[NULL]`, []bool{true}, nil},
+		{`[NULL,e[4.1][3.1,3.2]{},v[3.2]{}]`, []bool{true, false}, []bool{false}},
+		{`[v[3.1]{},NULL,v[3.2]{}]`, []bool{false, false}, []bool{true}},
+		{`[v[3.1]{},e[4.1][3.1,3.2]{},NULL]`, []bool{false, true}, []bool{false}},
+		{`[NULL,NULL,NULL]`, []bool{true, true}, []bool{true}},
+	}
+	for _, c := range tests {
+		var p BasicPath
+		err := p.Scan(c.b)
+		if err != nil {
+			t.Errorf("%s: %v", c.b, err)
+			continue
+		}
+		if len(p.Vertices) != len(c.nullV) || len(p.Edges) != len(c.nullE) {
+			t.Errorf("%s: got %d vertices and %d edges, want %d and %d", c.b, len(p.Vertices), len(p.Edges), len(c.nullV), len(c.nullE))
+			continue
+		}
+		for i, v := range p.Vertices {
+			if v.Valid == c.nullV[i] {
+				t.Errorf("%s: got vertex %d %s, want NULL == %t", c.b, i, v, c.nullV[i])
+			}
+		}
+		for i, e := range p.Edges {
+			if e.Valid == c.nullE[i] {
+				t.Errorf("%s: got edge %d %s, want NULL == %t", c.b, i, e, c.nullE[i])
+			}
+		}
+	}
+}
+
+func TestBasicPathSavePathError(t *testing.T) {
+	var v BasicVertex
+	d, err := v.readEntity([]byte(`v[3.1]{}`), scanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := (Edge{}).readEntity([]byte(`e[4.1][3.1,3.2]{}`), scanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The last element is an edge where a vertex is expected.
+	var p BasicPath
+	err = p.SavePath(true, []interface{}{d, e, e})
+	if err == nil {
+		t.Error("error expected for an edge at the end of a path")
+	}
+
+	// The path ends with an edge.
+	err = p.SavePath(true, []interface{}{d, e})
+	if err == nil {
+		t.Error("error expected for a path with an even number of elements")
+	}
+}
+
+func TestBasicPathString(t *testing.T) {
+	tests := []struct {
+		p    BasicPath
+		want string
+	}{
+		{BasicPath{}, "NULL"},
+		{BasicPath{Valid: true}, "[]"},
+		{makeTestPath(0), `[v[3.1]{"name":"v1"}]`},
+		{makeTestPath(1), `[v[3.1]{"name":"v1"},e[4.1][3.1,3.2]{"weight":1},v[3.2]{"name":"v2"}]`},
+	}
+	for _, c := range tests {
+		if s := c.p.String(); s != c.want {
+			t.Errorf("got %s, want %s", s, c.want)
+		}
+	}
+}
+
+func BenchmarkPathString(b *testing.B) {
+	for _, ne := range []int{1, 10, 100, 1000} {
+		p := makeTestPath(ne)
+		b.Run(fmt.Sprintf("edges=%d", ne), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = p.String()
+			}
+		})
+	}
+}
+
+func BenchmarkScanPath(b *testing.B) {
+	src := []byte(makeTestPath(100).String())
+	opts := []struct {
+		name string
+		opts []ScanOption
+	}{
+		{"all", nil},
+		{"SkipEdgeProperties", []ScanOption{SkipEdgeProperties()}},
+		{"SkipProperties", []ScanOption{SkipProperties()}},
+	}
+	for _, c := range opts {
+		dec := NewDecoder(c.opts...)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var p BasicPath
+			for i := 0; i < b.N; i++ {
+				_ = dec.ScanPath(src, &p)
+			}
+		})
+	}
+}
+
+func TestBasicPathSharedVertices(t *testing.T) {
+	mustScanPath := func(s string) BasicPath {
+		var p BasicPath
+		err := p.Scan(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	p := mustScanPath(`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{},e[4.2][3.2,3.3]{},v[3.3]{}]`)
+	q := mustScanPath(`[v[3.3]{},e[4.3][3.3,3.2]{},v[3.2]{},e[4.4][3.2,3.3]{},v[3.3]{}]`)
+	r := mustScanPath(`[v[3.4]{},e[4.5][3.4,3.5]{},v[3.5]{}]`)
+
+	if ids := p.VertexIds(); len(ids) != 3 || ids[0].String() != "3.1" || ids[2].String() != "3.3" {
+		t.Errorf("got %v, want [3.1 3.2 3.3]", ids)
+	}
+
+	if !p.Intersects(q) {
+		t.Errorf("got false, want true for %s and %s", p, q)
+	}
+	if shared := p.SharedVertices(q); len(shared) != 2 || shared[0].String() != "3.2" || shared[1].String() != "3.3" {
+		t.Errorf("got %v, want [3.2 3.3]", shared)
+	}
+	if shared := q.SharedVertices(p); len(shared) != 2 || shared[0].String() != "3.3" || shared[1].String() != "3.2" {
+		t.Errorf("got %v, want [3.3 3.2]", shared)
+	}
+
+	if p.Intersects(r) {
+		t.Errorf("got true, want false for %s and %s", p, r)
+	}
+	if shared := p.SharedVertices(r); shared != nil {
+		t.Errorf("got %v, want nil", shared)
+	}
+
+	var null BasicPath
+	if p.Intersects(null) || null.Intersects(p) {
+		t.Error("got true, want false for NULL path")
+	}
+	if null.VertexIds() != nil {
+		t.Error("got non-nil, want nil for NULL path")
+	}
+}
+
 func TestServerGraphpath(t *testing.T) {
 	skipUnlessServerTest(t)
 
@@ -98,3 +658,24 @@ func TestServerGraphpath(t *testing.T) {
 		}
 	}
 }
+
+func TestServerShortestPathNotFound(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	db := mustOpenAndSetGraph(t)
+	defer db.Close()
+
+	_, err := db.Exec(`CREATE (:spv {n: 1}), (:spv {n: 2})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var p BasicPath
+	q := `MATCH (a:spv {n: 1}), (b:spv {n: 2}) RETURN shortestpath((a)-[*]->(b))`
+	err = db.QueryRow(q).Scan(&p)
+	if err != nil {
+		t.Error(err)
+	} else if p.Found() {
+		t.Errorf("got %s, want NULL", p)
+	}
+}
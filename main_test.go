@@ -33,7 +33,9 @@ var (
 )
 
 func TestMain(m *testing.M) {
-	agTestServer = flag.Bool("ag.test.server", false, "Run server tests")
+	// AG_TEST_SERVER enables server tests without the flag, which is
+	// convenient for CI.
+	agTestServer = flag.Bool("ag.test.server", os.Getenv("AG_TEST_SERVER") != "", "Run server tests")
 	flag.Parse()
 
 	setUpServerTest()
@@ -0,0 +1,871 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DiffProperties compares two sets of properties key by key and returns the
+// differences between them.
+//
+// added holds the keys that exist only in new, removed holds the keys that
+// exist only in old, and changed holds the keys whose values differ. Values in
+// added and changed are taken from new and values in removed are taken from
+// old. Values are compared by value using reflect.DeepEqual, so nested
+// objects and arrays are compared element-wise and a change of type (e.g.
+// from number to string) is reported as changed.
+//
+// Each of the returned maps is non-nil even if it is empty.
+func DiffProperties(old, new map[string]interface{}) (added, changed, removed map[string]interface{}) {
+	added = make(map[string]interface{})
+	changed = make(map[string]interface{})
+	removed = make(map[string]interface{})
+
+	for k, nv := range new {
+		ov, ok := old[k]
+		if !ok {
+			added[k] = nv
+		} else if !reflect.DeepEqual(ov, nv) {
+			changed[k] = nv
+		}
+	}
+
+	for k, ov := range old {
+		if _, ok := new[k]; !ok {
+			removed[k] = ov
+		}
+	}
+
+	return
+}
+
+// PropertiesEqualIgnoring reports whether a and b have the same properties
+// except the keys in ignore. Values are compared by value as DiffProperties
+// does. ignore applies to the top-level keys only; nested objects are
+// compared as a whole, including all of their keys.
+func PropertiesEqualIgnoring(a, b map[string]interface{}, ignore ...string) bool {
+	skip := make(map[string]bool, len(ignore))
+	for _, k := range ignore {
+		skip[k] = true
+	}
+
+	n := 0
+	for k, av := range a {
+		if skip[k] {
+			continue
+		}
+		bv, ok := b[k]
+		if !ok || !reflect.DeepEqual(av, bv) {
+			return false
+		}
+		n++
+	}
+	for k := range b {
+		if !skip[k] {
+			n--
+		}
+	}
+	return n == 0
+}
+
+// RedactProperties returns a copy of m that is safe to log. Strings longer
+// than maxLen characters are truncated to maxLen characters followed by
+// "...", and the values of the keys in sensitiveKeys are replaced with "***".
+// maxLen <= 0 means no truncation. m is not modified.
+//
+// Nested objects and arrays are redacted recursively, and sensitiveKeys apply
+// at any depth. Values nested deeper than 32 levels are replaced with "***"
+// as well, since they are not inspected.
+func RedactProperties(m map[string]interface{}, maxLen int, sensitiveKeys ...string) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	sensitive := make(map[string]bool, len(sensitiveKeys))
+	for _, k := range sensitiveKeys {
+		sensitive[k] = true
+	}
+	return redactObject(m, maxLen, sensitive, 1)
+}
+
+const (
+	redactedValue  = "***"
+	maxRedactDepth = 32
+)
+
+func redactObject(m map[string]interface{}, maxLen int, sensitive map[string]bool, depth int) map[string]interface{} {
+	r := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if sensitive[k] {
+			r[k] = redactedValue
+		} else {
+			r[k] = redactValue(v, maxLen, sensitive, depth)
+		}
+	}
+	return r
+}
+
+func redactValue(v interface{}, maxLen int, sensitive map[string]bool, depth int) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if depth >= maxRedactDepth {
+			return redactedValue
+		}
+		return redactObject(v, maxLen, sensitive, depth+1)
+	case []interface{}:
+		if depth >= maxRedactDepth {
+			return redactedValue
+		}
+		r := make([]interface{}, len(v))
+		for i, e := range v {
+			r[i] = redactValue(e, maxLen, sensitive, depth+1)
+		}
+		return r
+	case string:
+		if maxLen > 0 && utf8.RuneCountInString(v) > maxLen {
+			i := 0
+			for n := 0; n < maxLen; n++ {
+				_, size := utf8.DecodeRuneInString(v[i:])
+				i += size
+			}
+			return v[:i] + "..."
+		}
+	}
+	return v
+}
+
+// MarshalProperties returns the JSON encoding of properties v to be written
+// to the database. Unlike json.Marshal, it does not escape <, >, and & in
+// strings since the result is not embedded in HTML.
+//
+// The keys of maps are sorted at every level, and arrays keep their order, so
+// equal maps always give the same bytes, which can be compared or cached.
+// json.Number is written as the number as it is, without going through
+// float64. Structs are written in the order of their fields.
+func MarshalProperties(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// remove the newline added by Encode
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+// MergeDeep decodes the JSON object b and merges it into dst. Objects are
+// merged recursively, and any other value in b, including arrays and null,
+// replaces the value of the same key in dst. dst must not be nil.
+func MergeDeep(dst map[string]interface{}, b []byte) error {
+	var src map[string]interface{}
+	err := json.Unmarshal(b, &src)
+	if err != nil {
+		return errors.New("invalid properties: " + err.Error())
+	}
+	mergeDeep(dst, src)
+	return nil
+}
+
+func mergeDeep(dst, src map[string]interface{}) {
+	for k, sv := range src {
+		sm, ok := sv.(map[string]interface{})
+		if !ok {
+			dst[k] = sv
+			continue
+		}
+		dm, ok := dst[k].(map[string]interface{})
+		if !ok {
+			dst[k] = sm
+			continue
+		}
+		mergeDeep(dm, sm)
+	}
+}
+
+// FlattenProperties flattens nested properties into a single level map whose
+// values are strings, which is suitable for tabular formats such as CSV.
+//
+// Keys of nested objects are joined with a dot (e.g. "address.city") and
+// elements of arrays are indexed from 0 in brackets (e.g. "tags[0]", or
+// "rows[1][0]" for nested arrays). Strings are stored as is, numbers are
+// formatted by strconv.FormatFloat with the 'f' format and the smallest
+// precision necessary, booleans are "true" or "false", and null is an empty
+// string. An empty object or array is stored as "{}" or "[]" so that its key
+// is not lost.
+func FlattenProperties(m map[string]interface{}) map[string]string {
+	f := make(map[string]string)
+	for k, v := range m {
+		flattenProperty(f, k, v)
+	}
+	return f
+}
+
+func flattenProperty(f map[string]string, key string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) < 1 {
+			f[key] = "{}"
+		}
+		for k, e := range v {
+			flattenProperty(f, key+"."+k, e)
+		}
+	case []interface{}:
+		if len(v) < 1 {
+			f[key] = "[]"
+		}
+		for i, e := range v {
+			flattenProperty(f, key+"["+strconv.Itoa(i)+"]", e)
+		}
+	case string:
+		f[key] = v
+	case float64:
+		f[key] = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		f[key] = string(v)
+	case bool:
+		f[key] = strconv.FormatBool(v)
+	case nil:
+		f[key] = ""
+	default:
+		f[key] = fmt.Sprint(v)
+	}
+}
+
+// GetPropertyPath returns the value at path in properties m. path is a subset
+// of the keys FlattenProperties makes: keys separated by '.', each followed by
+// any number of array indices in brackets, such as "address.geo.lat",
+// "tags[0]", and "matrix[1][2].name". Keys that contain '.' or '[' cannot be
+// reached, and there is no quoting, wildcard, or negative index.
+//
+// ok is false if path is malformed, or any key is missing, or any index is out
+// of range, or any value on the way is not an object or an array as path
+// expects.
+func GetPropertyPath(m map[string]interface{}, path string) (val interface{}, ok bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	val = m
+	for _, seg := range strings.Split(path, ".") {
+		key, idx, _ := strings.Cut(seg, "[")
+		if key == "" {
+			return nil, false
+		}
+		if val, ok = propertyByKey(val, key); !ok {
+			return nil, false
+		}
+		if idx == "" {
+			if strings.HasSuffix(seg, "[") {
+				return nil, false
+			}
+			continue
+		}
+
+		// idx is "0][1]" for "key[0][1]"
+		for _, s := range strings.Split(strings.TrimSuffix(idx, "]"), "][") {
+			i, err := strconv.Atoi(s)
+			if err != nil || i < 0 || s[0] == '+' {
+				return nil, false
+			}
+			a, isArray := val.([]interface{})
+			if !isArray || i >= len(a) {
+				return nil, false
+			}
+			val = a[i]
+		}
+		if !strings.HasSuffix(idx, "]") {
+			return nil, false
+		}
+	}
+	return val, true
+}
+
+func propertyByKey(v interface{}, key string) (interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		val, ok := m[key]
+		return val, ok
+	case PropertiesMap:
+		val, ok := m[key]
+		return val, ok
+	}
+	return nil, false
+}
+
+// PropertyPoint is a point stored in a property. It can be used as a field
+// type of properties.
+//
+// A point is read from either an array of two numbers ([x, y]) or an object
+// that has x and y ({"x": x, "y": y}), and is written as the object form.
+type PropertyPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// UnmarshalJSON implements the encoding/json Unmarshaler interface.
+func (p *PropertyPoint) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimLeft(b, " \t\r\n")
+	if string(b) == "null" {
+		// leave p unchanged as encoding/json does
+		return nil
+	}
+	if len(b) > 0 && b[0] == '[' {
+		var xy []float64
+		err := json.Unmarshal(b, &xy)
+		if err != nil {
+			return errors.New("invalid point: " + err.Error())
+		}
+		if len(xy) != 2 {
+			return fmt.Errorf("invalid point: %d coordinates", len(xy))
+		}
+		p.X, p.Y = xy[0], xy[1]
+		return nil
+	}
+
+	var xy struct {
+		X *float64 `json:"x"`
+		Y *float64 `json:"y"`
+	}
+	err := json.Unmarshal(b, &xy)
+	if err != nil {
+		return errors.New("invalid point: " + err.Error())
+	}
+	if xy.X == nil || xy.Y == nil {
+		return fmt.Errorf("invalid point: %s", b)
+	}
+	p.X, p.Y = *xy.X, *xy.Y
+	return nil
+}
+
+// PropertyTime is a date or time stored in a property. It can be used as a
+// field type of properties.
+//
+// AgensGraph has no temporal type for properties; a date or time is stored as
+// a JSON string, such as the one to_jsonb() gives for date, time, timetz,
+// timestamp, and timestamptz values. PropertyTime reads all of them, with
+// either "T" or a space between the date and the time:
+//
+//	"2024-01-02"                        date
+//	"15:04:05.123"                      time, on January 1, year 0
+//	"15:04:05+09:00"                    timetz, on January 1, year 0
+//	"2024-01-02T15:04:05.123"           timestamp
+//	"2024-01-02T15:04:05.123+09:00"     timestamptz
+//
+// A value with a UTC offset keeps the offset in a fixed zone; call Local or In
+// to convert it. A value without an offset is taken as UTC, since the zone it
+// was meant in is not stored. "infinity" and "-infinity" are not supported.
+//
+// PropertyTime is written as a JSON string in RFC 3339 form.
+type PropertyTime struct {
+	time.Time
+}
+
+var propertyTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z07",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999Z07:00",
+	"15:04:05.999999999Z07",
+	"15:04:05.999999999",
+}
+
+// UnmarshalJSON implements the encoding/json Unmarshaler interface.
+func (p *PropertyTime) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		// leave p unchanged as encoding/json does
+		return nil
+	}
+
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return errors.New("invalid time: " + err.Error())
+	}
+
+	// the text form of PostgreSQL has a space instead of "T"
+	if len(s) > 10 && s[10] == ' ' {
+		s = s[:10] + "T" + s[11:]
+	}
+	for _, layout := range propertyTimeLayouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			p.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time: %q", s)
+}
+
+// MarshalJSON implements the encoding/json Marshaler interface.
+func (p PropertyTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Time.Format(time.RFC3339Nano))
+}
+
+// PropertiesToURLValues converts properties to url.Values for query strings
+// and forms. Properties are flattened and stringified by FlattenProperties, so
+// each key has exactly one value; nested objects and arrays are flattened into
+// keys such as "address.city" and "tags[0]", null becomes an empty value, and
+// booleans become "true" or "false".
+func PropertiesToURLValues(m map[string]interface{}) url.Values {
+	f := FlattenProperties(m)
+	vs := make(url.Values, len(f))
+	for k, v := range f {
+		vs.Set(k, v)
+	}
+	return vs
+}
+
+// LazyProperties is a PropertiesSaver that keeps the raw properties of an
+// entity and decodes them on demand. It may be used as an embedded field of an
+// entity in place of a map of properties.
+//
+// SaveProperties only copies the raw properties, so scanning is cheap and an
+// invalid JSON object is not detected until the first call to Get. On the
+// first call to Get, the object is split into its top-level members and
+// only the requested member is decoded. Decoded values are cached for
+// subsequent calls. This pays off when only a few properties of a large
+// object are read; if most of the properties are read anyway, decoding them
+// eagerly into a map as BasicVertex and BasicEdge do is simpler and cheaper.
+//
+// LazyProperties is not safe for concurrent use.
+type LazyProperties struct {
+	raw     []byte
+	members map[string]json.RawMessage
+	values  map[string]interface{}
+}
+
+// SaveProperties implements PropertiesSaver interface. It stores a copy of b
+// and discards any previously decoded values.
+func (p *LazyProperties) SaveProperties(b []byte) error {
+	p.raw = append(p.raw[:0], b...)
+	p.members = nil
+	p.values = nil
+	return nil
+}
+
+// Raw returns the raw properties last stored by SaveProperties.
+func (p *LazyProperties) Raw() []byte {
+	return p.raw
+}
+
+// Get returns the value of the property key. ok is false if there is no such
+// property.
+//
+// An error will be returned if the raw properties are not a valid JSON object.
+func (p *LazyProperties) Get(key string) (val interface{}, ok bool, err error) {
+	if val, ok = p.values[key]; ok {
+		return
+	}
+
+	if p.members == nil {
+		err = json.Unmarshal(p.raw, &p.members)
+		if err != nil {
+			err = errors.New("invalid properties: " + err.Error())
+			return
+		}
+	}
+
+	m, ok := p.members[key]
+	if !ok {
+		return
+	}
+
+	err = json.Unmarshal(m, &val)
+	if err != nil {
+		return nil, false, errors.New("invalid property: " + err.Error())
+	}
+
+	if p.values == nil {
+		p.values = make(map[string]interface{})
+	}
+	p.values[key] = val
+
+	return
+}
+
+// PropertiesMap is properties decoded by encoding/json. Its Get* methods return
+// the value of a property as a Go primitive type.
+//
+// The strict variants (GetString, GetInt, GetFloat, and GetBool) return an
+// error unless the property has the corresponding JSON type. GetInt also
+// requires the number to be an integer.
+//
+// The lenient variants additionally accept the following values:
+//
+//	GetStringLenient: a number or a boolean, formatted by strconv
+//	GetIntLenient:    a string that strconv.ParseInt or strconv.ParseFloat
+//	                  accepts, if its value is an integer
+//	GetFloatLenient:  a string that strconv.ParseFloat accepts
+//	GetBoolLenient:   a string that strconv.ParseBool accepts
+//
+// All of them return an error if there is no such property.
+//
+// Convert the properties of BasicVertex and BasicEdge to use them:
+//
+//	n, err := PropertiesMap(v.Properties).GetIntLenient("age")
+type PropertiesMap map[string]interface{}
+
+func (m PropertiesMap) get(key string) (interface{}, error) {
+	v, ok := m[key]
+	if !ok {
+		return nil, fmt.Errorf("no property %q", key)
+	}
+	return v, nil
+}
+
+func propertyTypeError(key string, v interface{}, want string) error {
+	return fmt.Errorf("property %q is not %s: %T", key, want, v)
+}
+
+// GetString returns the value of the string property key.
+func (m PropertiesMap) GetString(key string) (string, error) {
+	v, err := m.get(key)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", propertyTypeError(key, v, "a string")
+	}
+	return s, nil
+}
+
+// GetInt returns the value of the integer property key.
+func (m PropertiesMap) GetInt(key string) (int64, error) {
+	v, err := m.get(key)
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case float64:
+		return floatToInt(key, n)
+	case json.Number:
+		return numberToInt(key, string(n))
+	}
+	return 0, propertyTypeError(key, v, "an integer")
+}
+
+// GetFloat returns the value of the number property key.
+func (m PropertiesMap) GetFloat(key string) (float64, error) {
+	v, err := m.get(key)
+	if err != nil {
+		return 0, err
+	}
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case json.Number:
+		return numberToFloat(key, string(n))
+	}
+	return 0, propertyTypeError(key, v, "a number")
+}
+
+// GetBool returns the value of the boolean property key.
+func (m PropertiesMap) GetBool(key string) (bool, error) {
+	v, err := m.get(key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, propertyTypeError(key, v, "a boolean")
+	}
+	return b, nil
+}
+
+// GetStringLenient is like GetString but also accepts a number or a boolean.
+func (m PropertiesMap) GetStringLenient(key string) (string, error) {
+	v, err := m.get(key)
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case json.Number:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", propertyTypeError(key, v, "a string")
+}
+
+// GetIntLenient is like GetInt but also accepts a string holding an integer.
+func (m PropertiesMap) GetIntLenient(key string) (int64, error) {
+	v, err := m.get(key)
+	if err != nil {
+		return 0, err
+	}
+	if s, ok := v.(string); ok {
+		return numberToInt(key, s)
+	}
+	return m.GetInt(key)
+}
+
+// GetFloatLenient is like GetFloat but also accepts a string holding a
+// number.
+func (m PropertiesMap) GetFloatLenient(key string) (float64, error) {
+	v, err := m.get(key)
+	if err != nil {
+		return 0, err
+	}
+	if s, ok := v.(string); ok {
+		return numberToFloat(key, s)
+	}
+	return m.GetFloat(key)
+}
+
+// GetBoolLenient is like GetBool but also accepts a string holding a boolean.
+func (m PropertiesMap) GetBoolLenient(key string) (bool, error) {
+	v, err := m.get(key)
+	if err != nil {
+		return false, err
+	}
+	if s, ok := v.(string); ok {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return false, fmt.Errorf("invalid property %q: %v", key, err)
+		}
+		return b, nil
+	}
+	return m.GetBool(key)
+}
+
+func floatToInt(key string, f float64) (int64, error) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("property %q is not an integer: %v", key, f)
+	}
+	return int64(f), nil
+}
+
+func numberToInt(key, s string) (int64, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return i, nil
+	}
+	f, err := numberToFloat(key, s)
+	if err != nil {
+		return 0, err
+	}
+	return floatToInt(key, f)
+}
+
+func numberToFloat(key, s string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid property %q: %v", key, err)
+	}
+	return f, nil
+}
+
+// nonFiniteMarker prefixes the strings that quoteNonFinite replaces NaN,
+// Infinity, and -Infinity with. It begins with NUL so that it is not likely
+// to be in real strings.
+const nonFiniteMarker = "\x00ag:"
+
+var nonFiniteTokens = []string{"NaN", "Infinity", "-Infinity"}
+
+// quoteNonFinite replaces NaN, Infinity, and -Infinity out of strings in b
+// with JSON strings of them prefixed with nonFiniteMarker so that b becomes
+// valid JSON. b is returned as it is if there is nothing to replace.
+func quoteNonFinite(b []byte) []byte {
+	var out []byte
+	last := 0
+	for i := 0; i < len(b); i++ {
+		if b[i] == '"' {
+			i = skipJSONString(b, i) - 1
+			continue
+		}
+		for _, t := range nonFiniteTokens {
+			if !bytes.HasPrefix(b[i:], []byte(t)) {
+				continue
+			}
+			out = append(out, b[last:i]...)
+			out = append(out, `"\u0000ag:`+t+`"`...)
+			i += len(t) - 1
+			last = i + 1
+			break
+		}
+	}
+	if out == nil {
+		return b
+	}
+	return append(out, b[last:]...)
+}
+
+// unmarshalNonFinite unmarshals b that may have NaN, Infinity, and -Infinity
+// as numbers.
+func unmarshalNonFinite(b []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	err := json.Unmarshal(quoteNonFinite(b), &m)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range m {
+		m[k] = restoreNonFinite(v)
+	}
+	return m, nil
+}
+
+func restoreNonFinite(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		switch v {
+		case nonFiniteMarker + "NaN":
+			return math.NaN()
+		case nonFiniteMarker + "Infinity":
+			return math.Inf(1)
+		case nonFiniteMarker + "-Infinity":
+			return math.Inf(-1)
+		}
+	case []interface{}:
+		for i, x := range v {
+			v[i] = restoreNonFinite(x)
+		}
+	case map[string]interface{}:
+		for k, x := range v {
+			v[k] = restoreNonFinite(x)
+		}
+	}
+	return v
+}
+
+// extendedJSONNumbers are the MongoDB extended JSON wrappers that
+// coerceExtendedJSON recognizes. Their values are strings.
+var extendedJSONNumbers = map[string]bool{
+	"$numberInt":     true,
+	"$numberLong":    true,
+	"$numberDouble":  true,
+	"$numberDecimal": true,
+}
+
+// coerceExtendedJSON replaces the extended JSON wrappers of numbers in b, such
+// as {"$numberLong": "123"}, with the numbers. If nonFinite is true, b may
+// have NaN, Infinity, and -Infinity, and "$numberDouble" may wrap them. b is
+// returned as it is if there is nothing to replace.
+func coerceExtendedJSON(b []byte, nonFinite bool) ([]byte, error) {
+	if !bytes.Contains(b, []byte(`"$number`)) {
+		return b, nil
+	}
+
+	if nonFinite {
+		b = quoteNonFinite(b)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err = coerceExtendedNumber(v, nonFinite)
+	if err != nil {
+		return nil, err
+	}
+	b, err = MarshalProperties(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if nonFinite {
+		// unquote what quoteNonFinite quoted
+		for _, t := range nonFiniteTokens {
+			b = bytes.ReplaceAll(b, []byte(`"\u0000ag:`+t+`"`), []byte(t))
+		}
+	}
+	return b, nil
+}
+
+func coerceExtendedNumber(v interface{}, nonFinite bool) (interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		for i, x := range v {
+			x, err := coerceExtendedNumber(x, nonFinite)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = x
+		}
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for k, x := range v {
+				if extendedJSONNumbers[k] {
+					return extendedNumber(k, x, nonFinite)
+				}
+			}
+		}
+		for k, x := range v {
+			x, err := coerceExtendedNumber(x, nonFinite)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = x
+		}
+	}
+	return v, nil
+}
+
+func extendedNumber(wrapper string, v interface{}, nonFinite bool) (interface{}, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("value of %s is not a string: %T", wrapper, v)
+	}
+
+	var err error
+	switch wrapper {
+	case "$numberInt":
+		_, err = strconv.ParseInt(s, 10, 32)
+	case "$numberLong":
+		_, err = strconv.ParseInt(s, 10, 64)
+	case "$numberDouble":
+		for _, t := range nonFiniteTokens {
+			if s == t {
+				if !nonFinite {
+					return nil, fmt.Errorf("%s %s is not allowed without NonFiniteNumbers", wrapper, s)
+				}
+				return nonFiniteMarker + s, nil
+			}
+		}
+		fallthrough
+	default:
+		// json.Number must be a valid JSON number
+		if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || !json.Valid([]byte(s)) {
+			return nil, fmt.Errorf("invalid %s: %q", wrapper, s)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", wrapper, err)
+	}
+	return json.Number(s), nil
+}
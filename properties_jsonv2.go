@@ -0,0 +1,48 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+)
+
+// PropertiesTextSaver is an interface used by ScanEntity. It is only
+// available when built with GOEXPERIMENT=jsonv2.
+type PropertiesTextSaver interface {
+	// If an entity implements PropertiesTextSaver, ScanEntity calls
+	// SavePropertiesText instead of MergeProperties, SaveProperties, or
+	// json.Unmarshal. dec reads the properties as a stream of JSON tokens,
+	// so that fields can be picked out of huge properties without decoding
+	// all of them.
+	//
+	// dec must not be used after SavePropertiesText returns since it reads
+	// from the buffer that may be reused as for SaveProperties.
+	SavePropertiesText(dec *jsontext.Decoder) error
+}
+
+func init() {
+	saveTextProperties = func(entity Entity, b []byte) (bool, error) {
+		s, ok := entity.(PropertiesTextSaver)
+		if !ok {
+			return false, nil
+		}
+		return true, s.SavePropertiesText(jsontext.NewDecoder(bytes.NewReader(b)))
+	}
+}
@@ -0,0 +1,74 @@
+//go:build go1.27 && goexperiment.jsonv2
+
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"encoding/json/jsontext"
+	"errors"
+	"testing"
+)
+
+type streamingVertex struct {
+	VertexHeader
+	name string
+}
+
+// SavePropertiesText reads "name" and skips the other properties.
+func (v *streamingVertex) SavePropertiesText(dec *jsontext.Decoder) error {
+	if _, err := dec.ReadToken(); err != nil {
+		return err
+	}
+	for dec.PeekKind() != '}' {
+		k, err := dec.ReadToken()
+		if err != nil {
+			return err
+		}
+		if k.String() != "name" {
+			if err := dec.SkipValue(); err != nil {
+				return err
+			}
+			continue
+		}
+		tok, err := dec.ReadToken()
+		if err != nil {
+			return err
+		}
+		if tok.Kind() != '"' {
+			return errors.New("name is not a string")
+		}
+		v.name = tok.String()
+	}
+	return nil
+}
+
+func TestScanEntityPropertiesText(t *testing.T) {
+	var v streamingVertex
+	err := ScanEntity([]byte(`v[3.1]{"big": {"a": [1, 2, 3]}, "name": "go"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.name != "go" {
+		t.Errorf("got %q, want %q", v.name, "go")
+	}
+
+	err = ScanEntity([]byte(`v[3.1]{"name": 1}`), &v)
+	if err == nil {
+		t.Error("error expected for non-string name")
+	}
+}
@@ -0,0 +1,618 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustUnmarshalProperties(s string) map[string]interface{} {
+	var m map[string]interface{}
+	err := json.Unmarshal([]byte(s), &m)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestDiffProperties(t *testing.T) {
+	tests := []struct {
+		old     string
+		new     string
+		added   string
+		changed string
+		removed string
+	}{
+		{`{}`, `{}`, `{}`, `{}`, `{}`},
+		{`{"a": 1}`, `{"a": 1}`, `{}`, `{}`, `{}`},
+		{`{"a": 1}`, `{"b": 2}`, `{"b": 2}`, `{}`, `{"a": 1}`},
+		{`{"a": 1}`, `{"a": "1"}`, `{}`, `{"a": "1"}`, `{}`},
+		{`{"o": {"x": [1, 2]}}`, `{"o": {"x": [1, 2]}}`, `{}`, `{}`, `{}`},
+		{`{"o": {"x": [1, 2]}}`, `{"o": {"x": [2, 1]}}`, `{}`, `{"o": {"x": [2, 1]}}`, `{}`},
+	}
+	for _, c := range tests {
+		added, changed, removed := DiffProperties(mustUnmarshalProperties(c.old), mustUnmarshalProperties(c.new))
+		if want := mustUnmarshalProperties(c.added); !reflect.DeepEqual(added, want) {
+			t.Errorf("%s -> %s: got added %v, want %v", c.old, c.new, added, want)
+		}
+		if want := mustUnmarshalProperties(c.changed); !reflect.DeepEqual(changed, want) {
+			t.Errorf("%s -> %s: got changed %v, want %v", c.old, c.new, changed, want)
+		}
+		if want := mustUnmarshalProperties(c.removed); !reflect.DeepEqual(removed, want) {
+			t.Errorf("%s -> %s: got removed %v, want %v", c.old, c.new, removed, want)
+		}
+	}
+}
+
+func TestDiffPropertiesNil(t *testing.T) {
+	added, changed, removed := DiffProperties(nil, map[string]interface{}{"a": 1.0})
+	if len(added) != 1 || len(changed) != 0 || len(removed) != 0 {
+		t.Errorf("got %v, %v, %v, want one added key", added, changed, removed)
+	}
+}
+
+func TestPropertiesEqualIgnoring(t *testing.T) {
+	tests := []struct {
+		a      string
+		b      string
+		ignore []string
+		equal  bool
+	}{
+		{`{}`, `{}`, nil, true},
+		{`{"a": 1}`, `{"a": 1}`, nil, true},
+		{`{"a": 1, "t": 1}`, `{"a": 1, "t": 2}`, nil, false},
+		{`{"a": 1, "t": 1}`, `{"a": 1, "t": 2}`, []string{"t"}, true},
+		{`{"a": 1, "t": 1}`, `{"a": 1}`, []string{"t"}, true},
+		{`{"a": 1}`, `{"a": 1, "b": 2}`, []string{"t"}, false},
+		{`{"a": 1}`, `{"b": 1}`, nil, false},
+		{`{"o": {"x": [1, 2]}}`, `{"o": {"x": [1, 2]}}`, nil, true},
+		{`{"o": {"t": 1}}`, `{"o": {"t": 2}}`, []string{"t"}, false},
+	}
+	for _, c := range tests {
+		a, b := mustUnmarshalProperties(c.a), mustUnmarshalProperties(c.b)
+		if equal := PropertiesEqualIgnoring(a, b, c.ignore...); equal != c.equal {
+			t.Errorf("got %t for %s and %s ignoring %q, want %t", equal, c.a, c.b, c.ignore, c.equal)
+		}
+		if equal := PropertiesEqualIgnoring(b, a, c.ignore...); equal != c.equal {
+			t.Errorf("got %t for %s and %s ignoring %q, want %t", equal, c.b, c.a, c.ignore, c.equal)
+		}
+	}
+}
+
+func TestRedactProperties(t *testing.T) {
+	m := map[string]interface{}{
+		"name":     "abcdefgh",
+		"city":     "서울특별시",
+		"password": "secret",
+		"n":        float64(1),
+		"o": map[string]interface{}{
+			"token": map[string]interface{}{"a": "b"},
+			"list":  []interface{}{"abcdefgh", map[string]interface{}{"password": 1}},
+		},
+	}
+	want := map[string]interface{}{
+		"name":     "abcd...",
+		"city":     "서울특별...",
+		"password": "***",
+		"n":        float64(1),
+		"o": map[string]interface{}{
+			"token": "***",
+			"list":  []interface{}{"abcd...", map[string]interface{}{"password": "***"}},
+		},
+	}
+	r := RedactProperties(m, 4, "password", "token")
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %v, want %v", r, want)
+	}
+	if m["password"] != "secret" || m["o"].(map[string]interface{})["list"].([]interface{})[0] != "abcdefgh" {
+		t.Errorf("got %v, want the original unchanged", m)
+	}
+
+	if r := RedactProperties(m, 0); !reflect.DeepEqual(r, m) {
+		t.Errorf("got %v, want %v", r, m)
+	}
+	if r := RedactProperties(nil, 4); r != nil {
+		t.Errorf("got %v, want nil", r)
+	}
+
+	// too deep
+	var deep interface{} = "x"
+	for i := 0; i < 40; i++ {
+		deep = map[string]interface{}{"d": deep}
+	}
+	r = RedactProperties(deep.(map[string]interface{}), 0)
+	var v interface{} = r
+	for i := 0; i < 32; i++ {
+		v = v.(map[string]interface{})["d"]
+	}
+	if v != "***" {
+		t.Errorf("got %v at depth 32, want ***", v)
+	}
+}
+
+func TestMarshalProperties(t *testing.T) {
+	m := map[string]interface{}{"q": "a < b && b > c", "n": 1}
+	b, err := MarshalProperties(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"n":1,"q":"a < b && b > c"}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var v BasicVertex
+	err = v.UnmarshalText([]byte(`v[3.1]{"q": "R&D <team>"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := v.String(), `v[3.1]{"q":"R&D <team>"}`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	_, err = MarshalProperties(map[string]interface{}{"f": func() {}})
+	if err == nil {
+		t.Error("error expected for unsupported value")
+	}
+}
+
+type mergingVertex struct {
+	VertexHeader
+	Properties map[string]interface{}
+}
+
+func (v *mergingVertex) MergeProperties(b []byte) error {
+	if v.Properties == nil {
+		v.Properties = make(map[string]interface{})
+	}
+	return MergeDeep(v.Properties, b)
+}
+
+func TestMarshalPropertiesSorted(t *testing.T) {
+	var m map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(`{"z": 1, "a": {"y": [3, {"b": 1, "a": 2}, 1], "x": "<&>"}, "n": 12345678901234567890}`))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"a":{"x":"<&>","y":[3,{"a":2,"b":1},1]},"n":12345678901234567890,"z":1}`
+	for i := 0; i < 10; i++ {
+		b, err := MarshalProperties(m)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(b) != want {
+			t.Fatalf("got %s, want %s", b, want)
+		}
+	}
+
+	if _, err := MarshalProperties(map[string]interface{}{"c": make(chan int)}); err == nil {
+		t.Error("error expected for chan")
+	}
+}
+
+func TestMergeProperties(t *testing.T) {
+	rows := []string{
+		`v[3.1]{"name": "go", "addr": {"city": "Seoul"}, "tags": ["a"]}`,
+		`v[3.1]{"age": 15, "addr": {"zip": "04524"}, "tags": ["b"]}`,
+		`v[3.1]{"name": "ag", "addr": {"city": null}}`,
+	}
+
+	var v mergingVertex
+	for _, r := range rows {
+		err := ScanEntity([]byte(r), &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := mustUnmarshalProperties(`{"name": "ag", "age": 15, "addr": {"city": null, "zip": "04524"}, "tags": ["b"]}`)
+	if !reflect.DeepEqual(v.Properties, want) {
+		t.Errorf("got %v, want %v", v.Properties, want)
+	}
+
+	err := ScanEntity([]byte(`v[3.1][1]`), &v)
+	if err == nil {
+		t.Error("error expected for non-object properties")
+	}
+}
+
+func TestFlattenProperties(t *testing.T) {
+	m := mustUnmarshalProperties(`{
+		"name": "go",
+		"age": 15,
+		"score": 0.5,
+		"active": true,
+		"nothing": null,
+		"address": {"city": "Seoul", "geo": {"lat": 37.5}},
+		"tags": ["a", "b"],
+		"rows": [[1, 2], [3]],
+		"empty": {},
+		"none": []
+	}`)
+	want := map[string]string{
+		"name":            "go",
+		"age":             "15",
+		"score":           "0.5",
+		"active":          "true",
+		"nothing":         "",
+		"address.city":    "Seoul",
+		"address.geo.lat": "37.5",
+		"tags[0]":         "a",
+		"tags[1]":         "b",
+		"rows[0][0]":      "1",
+		"rows[0][1]":      "2",
+		"rows[1][0]":      "3",
+		"empty":           "{}",
+		"none":            "[]",
+	}
+	if f := FlattenProperties(m); !reflect.DeepEqual(f, want) {
+		t.Errorf("got %v, want %v", f, want)
+	}
+}
+
+type placeVertex struct {
+	VertexHeader `json:"-"`
+	Location     PropertyPoint
+}
+
+func TestGetPropertyPath(t *testing.T) {
+	m := mustUnmarshalProperties(`{"address": {"geo": {"lat": 37.5}}, "tags": ["a", "b"], "matrix": [[1, 2], [3, {"name": "x"}]], "n": null}`)
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"address.geo.lat", 37.5},
+		{"tags[0]", "a"},
+		{"tags[1]", "b"},
+		{"matrix[1][0]", float64(3)},
+		{"matrix[1][1].name", "x"},
+		{"n", nil},
+	}
+	for _, c := range tests {
+		v, ok := GetPropertyPath(m, c.path)
+		if !ok {
+			t.Errorf("got false for %s, want %v", c.path, c.want)
+		} else if v != c.want {
+			t.Errorf("got %v for %s, want %v", v, c.path, c.want)
+		}
+	}
+
+	if v, ok := GetPropertyPath(m, "address.geo"); !ok || !reflect.DeepEqual(v, map[string]interface{}{"lat": 37.5}) {
+		t.Errorf("got %v, %t, want the geo object", v, ok)
+	}
+	if v, ok := GetPropertyPath(map[string]interface{}{"p": PropertiesMap{"q": 1}}, "p.q"); !ok || v != 1 {
+		t.Errorf("got %v, %t, want 1", v, ok)
+	}
+
+	bad := []string{
+		"", ".", "missing", "address.zip", "address.geo.lat.x", "tags[2]", "tags[-1]", "tags[+1]",
+		"tags[x]", "tags[0", "tags[", "tags[]", "tags0]", "tags[0]x", "address[0]", "tags.0",
+		"matrix[0][2]", "matrix[0]][1]", ".tags", "tags.", "n.x", "[0]",
+	}
+	for _, p := range bad {
+		if v, ok := GetPropertyPath(m, p); ok {
+			t.Errorf("got %v, true for %q, want false", v, p)
+		}
+	}
+	if _, ok := GetPropertyPath(nil, "a"); ok {
+		t.Error("got true for nil, want false")
+	}
+}
+
+func TestPropertyPoint(t *testing.T) {
+	tests := []string{
+		`v[3.1]{"location": [1.5, -2]}`,
+		`v[3.1]{"location": {"x": 1.5, "y": -2}}`,
+	}
+	for _, b := range tests {
+		var v placeVertex
+		err := ScanEntity([]byte(b), &v)
+		if err != nil {
+			t.Error(err)
+		} else if v.Location != (PropertyPoint{1.5, -2}) {
+			t.Errorf("got %v, want {1.5 -2}", v.Location)
+		}
+	}
+
+	b, err := json.Marshal(PropertyPoint{1.5, -2})
+	if err != nil {
+		t.Error(err)
+	} else if want := `{"x":1.5,"y":-2}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	bad := []string{`[1]`, `[1, 2, 3]`, `["1", "2"]`, `{"x": 1}`, `"1,2"`}
+	for _, b := range bad {
+		var p PropertyPoint
+		err := json.Unmarshal([]byte(b), &p)
+		if err == nil {
+			t.Errorf("error expected for %s", b)
+		}
+	}
+}
+
+func TestPropertyTime(t *testing.T) {
+	kst := time.FixedZone("", 9*60*60)
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{`"2024-01-02"`, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`"15:04:05.123"`, time.Date(0, 1, 1, 15, 4, 5, 123000000, time.UTC)},
+		{`"15:04:05+09:00"`, time.Date(0, 1, 1, 15, 4, 5, 0, kst)},
+		{`"2024-01-02T15:04:05"`, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{`"2024-01-02T15:04:05.123456+09:00"`, time.Date(2024, 1, 2, 15, 4, 5, 123456000, kst)},
+		{`"2024-01-02 15:04:05+09"`, time.Date(2024, 1, 2, 15, 4, 5, 0, kst)},
+		{`"2024-01-02T06:04:05Z"`, time.Date(2024, 1, 2, 6, 4, 5, 0, time.UTC)},
+	}
+	for _, c := range tests {
+		var p PropertyTime
+		err := json.Unmarshal([]byte(c.s), &p)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		_, off := p.Zone()
+		_, wantOff := c.want.Zone()
+		if !p.Equal(c.want) || off != wantOff {
+			t.Errorf("got %s for %s, want %s", p.Time, c.s, c.want)
+		}
+	}
+
+	for _, s := range []string{`"infinity"`, `"2024-13-01"`, `20240102`, `""`} {
+		var p PropertyTime
+		err := json.Unmarshal([]byte(s), &p)
+		if err == nil {
+			t.Errorf("error expected for %s", s)
+		}
+	}
+
+	var v struct {
+		At PropertyTime `json:"at"`
+	}
+	err := json.Unmarshal([]byte(`{"at": "2024-01-02 15:04:05.5+09:00"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	} else if want := `{"at":"2024-01-02T15:04:05.5+09:00"}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestPropertiesToURLValues(t *testing.T) {
+	m := mustUnmarshalProperties(`{"name": "go & ag", "age": 15, "active": false, "nothing": null, "tags": ["a", "b"], "addr": {"city": "Seoul"}}`)
+	vs := PropertiesToURLValues(m)
+
+	want := "active=false&addr.city=Seoul&age=15&name=go+%26+ag&nothing=&tags%5B0%5D=a&tags%5B1%5D=b"
+	if s := vs.Encode(); s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
+
+type lazyVertex struct {
+	VertexHeader
+	LazyProperties
+}
+
+func (v *lazyVertex) Scan(src interface{}) error {
+	return ScanEntity(src, v)
+}
+
+func TestLazyProperties(t *testing.T) {
+	var v lazyVertex
+	err := v.Scan([]byte(`v[3.1]{"name": "go", "tags": ["a", "b"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, ok, err := v.Get("name")
+	if err != nil {
+		t.Error(err)
+	} else if !ok || name != "go" {
+		t.Errorf(`got %v, %t, want "go", true`, name, ok)
+	}
+
+	tags, ok, err := v.Get("tags")
+	if err != nil {
+		t.Error(err)
+	} else if want := []interface{}{"a", "b"}; !ok || !reflect.DeepEqual(tags, want) {
+		t.Errorf("got %v, %t, want %v, true", tags, ok, want)
+	}
+
+	_, ok, err = v.Get("missing")
+	if err != nil {
+		t.Error(err)
+	} else if ok {
+		t.Error("got true, want false for missing property")
+	}
+
+	err = v.Scan([]byte(`v[3.2]{"name": "ag"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, _, _ = v.Get("name")
+	if name != "ag" {
+		t.Errorf(`got %v, want "ag"`, name)
+	}
+}
+
+func TestLazyPropertiesError(t *testing.T) {
+	var p LazyProperties
+	err := p.SaveProperties([]byte(`{"name": `))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = p.Get("name")
+	if err == nil {
+		t.Errorf("error expected for %s", p.Raw())
+	}
+}
+
+func TestPropertiesMapGet(t *testing.T) {
+	m := PropertiesMap(mustUnmarshalProperties(`{"s": "go", "n": 15, "f": 1.5, "b": true, "si": "42", "sf": "2.5", "sb": "false", "sx": "x"}`))
+
+	if s, err := m.GetString("s"); err != nil || s != "go" {
+		t.Errorf(`got %q, %v, want "go"`, s, err)
+	}
+	if i, err := m.GetInt("n"); err != nil || i != 15 {
+		t.Errorf("got %d, %v, want 15", i, err)
+	}
+	if f, err := m.GetFloat("f"); err != nil || f != 1.5 {
+		t.Errorf("got %g, %v, want 1.5", f, err)
+	}
+	if b, err := m.GetBool("b"); err != nil || !b {
+		t.Errorf("got %t, %v, want true", b, err)
+	}
+
+	strictErrors := []func() error{
+		func() error { _, err := m.GetString("n"); return err },
+		func() error { _, err := m.GetInt("f"); return err },
+		func() error { _, err := m.GetInt("si"); return err },
+		func() error { _, err := m.GetFloat("sf"); return err },
+		func() error { _, err := m.GetBool("sb"); return err },
+		func() error { _, err := m.GetString("missing"); return err },
+	}
+	for i, f := range strictErrors {
+		if f() == nil {
+			t.Errorf("error expected for strict case %d", i)
+		}
+	}
+}
+
+func TestPropertiesMapGetLenient(t *testing.T) {
+	m := PropertiesMap(mustUnmarshalProperties(`{"n": 15, "f": 1.5, "b": true, "si": "42", "sfi": "1e3", "sf": "2.5", "sb": "false", "sx": "x"}`))
+
+	if s, err := m.GetStringLenient("n"); err != nil || s != "15" {
+		t.Errorf(`got %q, %v, want "15"`, s, err)
+	}
+	if s, err := m.GetStringLenient("b"); err != nil || s != "true" {
+		t.Errorf(`got %q, %v, want "true"`, s, err)
+	}
+	if i, err := m.GetIntLenient("si"); err != nil || i != 42 {
+		t.Errorf("got %d, %v, want 42", i, err)
+	}
+	if i, err := m.GetIntLenient("sfi"); err != nil || i != 1000 {
+		t.Errorf("got %d, %v, want 1000", i, err)
+	}
+	if i, err := m.GetIntLenient("n"); err != nil || i != 15 {
+		t.Errorf("got %d, %v, want 15", i, err)
+	}
+	if f, err := m.GetFloatLenient("sf"); err != nil || f != 2.5 {
+		t.Errorf("got %g, %v, want 2.5", f, err)
+	}
+	if b, err := m.GetBoolLenient("sb"); err != nil || b {
+		t.Errorf("got %t, %v, want false", b, err)
+	}
+
+	lenientErrors := []func() error{
+		func() error { _, err := m.GetIntLenient("sf"); return err },
+		func() error { _, err := m.GetIntLenient("sx"); return err },
+		func() error { _, err := m.GetFloatLenient("sx"); return err },
+		func() error { _, err := m.GetBoolLenient("sx"); return err },
+		func() error { _, err := m.GetIntLenient("missing"); return err },
+	}
+	for i, f := range lenientErrors {
+		if f() == nil {
+			t.Errorf("error expected for lenient case %d", i)
+		}
+	}
+}
+
+func TestNonFiniteNumbers(t *testing.T) {
+	src := []byte(`v[3.1]{"nan": NaN, "inf": Infinity, "ninf": -Infinity, "s": "NaN", "a": [1, NaN], "o": {"x": Infinity}}`)
+
+	var v BasicVertex
+	err := ScanEntity(src, &v)
+	if err == nil {
+		t.Error("error expected for NaN without NonFiniteNumbers")
+	}
+
+	err = ScanEntity(src, &v, NonFiniteNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := v.Properties
+	if f, ok := p["nan"].(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("got %v, want NaN", p["nan"])
+	}
+	if f, ok := p["inf"].(float64); !ok || !math.IsInf(f, 1) {
+		t.Errorf("got %v, want +Inf", p["inf"])
+	}
+	if f, ok := p["ninf"].(float64); !ok || !math.IsInf(f, -1) {
+		t.Errorf("got %v, want -Inf", p["ninf"])
+	}
+	if p["s"] != "NaN" {
+		t.Errorf(`got %v, want "NaN"`, p["s"])
+	}
+	if a := p["a"].([]interface{}); a[0] != float64(1) || !math.IsNaN(a[1].(float64)) {
+		t.Errorf("got %v, want [1 NaN]", a)
+	}
+	if f := p["o"].(map[string]interface{})["x"].(float64); !math.IsInf(f, 1) {
+		t.Errorf("got %v, want +Inf", f)
+	}
+
+	var e BasicEdge
+	err = ScanEntity([]byte(`e[4.1][3.1,3.2]{"w": -Infinity}`), &e, NonFiniteNumbers())
+	if err != nil {
+		t.Error(err)
+	} else if f, _ := e.Properties["w"].(float64); !math.IsInf(f, -1) {
+		t.Errorf("got %v, want -Inf", e.Properties["w"])
+	}
+}
+
+func TestNonFiniteNumbersStrict(t *testing.T) {
+	var v BasicVertex
+	err := ScanEntity([]byte(`v[3.1]{"x": NaN, "y": -Infinity}`), &v, Strict(), NonFiniteNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := v.Properties["x"].(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("got %v, want NaN", v.Properties["x"])
+	}
+
+	err = ScanEntity([]byte(`v[3.1]{"x": NaN, "x": 1}`), &v, Strict(), NonFiniteNumbers())
+	if err == nil {
+		t.Error("error expected for duplicate keys")
+	}
+}
+
+func TestQuoteNonFinite(t *testing.T) {
+	tests := []struct {
+		b    string
+		want string
+	}{
+		{`{"a": 1}`, `{"a": 1}`},
+		{`{"NaN": NaN}`, `{"NaN": "\u0000ag:NaN"}`},
+		{`[-Infinity,Infinity]`, `["\u0000ag:-Infinity","\u0000ag:Infinity"]`},
+		{`{"s": "a\"NaN"}`, `{"s": "a\"NaN"}`},
+	}
+	for _, c := range tests {
+		if got := string(quoteNonFinite([]byte(c.b))); got != c.want {
+			t.Errorf("got %s, want %s", got, c.want)
+		}
+	}
+}
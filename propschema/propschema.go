@@ -0,0 +1,269 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package propschema validates the properties of vertices and edges against a
+// JSON Schema document.
+//
+// It implements a minimal subset of JSON Schema that is enough to check the
+// shape of properties; type, enum, properties, required,
+// additionalProperties, items, minimum, maximum, minLength, maxLength, and
+// pattern. The other keywords are ignored. It is kept out of package ag so
+// that programs that do not validate properties do not depend on it.
+package propschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidatePropertiesSchema reports whether the properties b conform to the
+// JSON Schema document schema. It may be called from SaveProperties of
+// PropertiesSaver to reject properties before they are stored.
+//
+// An error will be returned if b or schema is not valid JSON, or b does not
+// conform to schema. In the latter case, the error is *ValidationError.
+func ValidatePropertiesSchema(b []byte, schema []byte) error {
+	var s interface{}
+	err := decode(schema, &s)
+	if err != nil {
+		return errors.New("invalid schema: " + err.Error())
+	}
+
+	var v interface{}
+	err = decode(b, &v)
+	if err != nil {
+		return errors.New("invalid properties: " + err.Error())
+	}
+
+	return validate(v, s, "")
+}
+
+func decode(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// ValidationError is returned by ValidatePropertiesSchema if the properties
+// do not conform to the schema.
+type ValidationError struct {
+	// Path is the JSON Pointer to the value that does not conform (e.g.
+	// "/address/city"). It is empty for the properties themselves.
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return "properties " + e.Message
+	}
+	return fmt.Sprintf("property %s %s", e.Path, e.Message)
+}
+
+func fail(path string, format string, a ...interface{}) error {
+	return &ValidationError{path, fmt.Sprintf(format, a...)}
+}
+
+func validate(v interface{}, schema interface{}, path string) error {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			return fail(path, "is not allowed")
+		}
+		return nil
+	case map[string]interface{}:
+		return validateObject(v, s, path)
+	default:
+		return fmt.Errorf("invalid schema at %q: %T", path, schema)
+	}
+}
+
+func validateObject(v interface{}, s map[string]interface{}, path string) error {
+	if t, ok := s["type"]; ok {
+		err := validateType(v, t, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, x := range e {
+			if equal(v, x) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail(path, "is not one of the enumerated values")
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return validateProperties(v, s, path)
+	case []interface{}:
+		if items, ok := s["items"]; ok {
+			for i, x := range v {
+				err := validate(x, items, fmt.Sprintf("%s/%d", path, i))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := float64(utf8.RuneCountInString(v))
+		if m, ok := number(s["minLength"]); ok && n < m {
+			return fail(path, "is shorter than %v", s["minLength"])
+		}
+		if m, ok := number(s["maxLength"]); ok && n > m {
+			return fail(path, "is longer than %v", s["maxLength"])
+		}
+		if p, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("invalid pattern at %q: %v", path, err)
+			}
+			if !re.MatchString(v) {
+				return fail(path, "does not match %q", p)
+			}
+		}
+	case json.Number:
+		n, _ := v.Float64()
+		if m, ok := number(s["minimum"]); ok && n < m {
+			return fail(path, "is less than %v", s["minimum"])
+		}
+		if m, ok := number(s["maximum"]); ok && n > m {
+			return fail(path, "is greater than %v", s["maximum"])
+		}
+	}
+
+	return nil
+}
+
+func validateProperties(v map[string]interface{}, s map[string]interface{}, path string) error {
+	if r, ok := s["required"].([]interface{}); ok {
+		for _, k := range r {
+			k, _ := k.(string)
+			if _, ok := v[k]; !ok {
+				return fail(path, "has no required property %q", k)
+			}
+		}
+	}
+
+	props, _ := s["properties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+
+	// Visit the keys in order so that the same violation is reported each time.
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		x := v[k]
+		p := path + "/" + escape(k)
+		if ps, ok := props[k]; ok {
+			err := validate(x, ps, p)
+			if err != nil {
+				return err
+			}
+		} else if hasAdditional {
+			err := validate(x, additional, p)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateType(v interface{}, t interface{}, path string) error {
+	var ts []interface{}
+	switch t := t.(type) {
+	case string:
+		ts = []interface{}{t}
+	case []interface{}:
+		ts = t
+	default:
+		return fmt.Errorf("invalid type at %q: %v", path, t)
+	}
+
+	for _, t := range ts {
+		if t, ok := t.(string); ok && hasType(v, t) {
+			return nil
+		}
+	}
+	return fail(path, "is not of type %v", t)
+}
+
+func hasType(v interface{}, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		if t == "integer" {
+			f, err := v.Float64()
+			return err == nil && f == float64(int64(f))
+		}
+		return false
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	default:
+		return false
+	}
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// equal compares JSON values. Numbers are compared by their values.
+func equal(x, y interface{}) bool {
+	if n, ok := number(x); ok {
+		m, ok := number(y)
+		return ok && n == m
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// escape escapes k as a reference token of JSON Pointer.
+func escape(k string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(k)
+}
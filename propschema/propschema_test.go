@@ -0,0 +1,104 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package propschema
+
+import (
+	"errors"
+	"testing"
+)
+
+const personSchema = `{
+	"type": "object",
+	"required": ["name"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "maxLength": 8},
+		"age": {"type": "integer", "minimum": 0, "maximum": 150},
+		"role": {"enum": ["admin", "user"]},
+		"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"address": {
+			"type": "object",
+			"properties": {"city": {"type": "string"}},
+			"additionalProperties": false
+		},
+		"nick": {"type": ["string", "null"]}
+	}
+}`
+
+func TestValidatePropertiesSchema(t *testing.T) {
+	tests := []struct {
+		b    string
+		path string
+		ok   bool
+	}{
+		{`{"name": "go"}`, "", true},
+		{`{"name": "go", "age": 10, "role": "user", "tags": ["a"], "address": {"city": "Seoul"}, "nick": null}`, "", true},
+		{`{"name": "go", "unknown": true}`, "", true},
+		{`{"name": "go", "email": "go@example.com"}`, "", true},
+		{`{}`, "", false},
+		{`[]`, "", false},
+		{`{"name": ""}`, "/name", false},
+		{`{"name": "too long name"}`, "/name", false},
+		{`{"name": 1}`, "/name", false},
+		{`{"name": "go", "age": 1.5}`, "/age", false},
+		{`{"name": "go", "age": -1}`, "/age", false},
+		{`{"name": "go", "age": 151}`, "/age", false},
+		{`{"name": "go", "role": "root"}`, "/role", false},
+		{`{"name": "go", "email": "go"}`, "/email", false},
+		{`{"name": "go", "tags": ["a", 1]}`, "/tags/1", false},
+		{`{"name": "go", "address": {"zip": "0"}}`, "/address/zip", false},
+		{`{"name": "go", "nick": 1}`, "/nick", false},
+		{`{"name": 1, "tags": [1], "age": -1}`, "/age", false},
+	}
+	for _, c := range tests {
+		err := ValidatePropertiesSchema([]byte(c.b), []byte(personSchema))
+		if c.ok {
+			if err != nil {
+				t.Errorf("%s: %v", c.b, err)
+			}
+			continue
+		}
+
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: got %v, want *ValidationError", c.b, err)
+		} else if verr.Path != c.path {
+			t.Errorf("%s: got path %q, want %q", c.b, verr.Path, c.path)
+		}
+	}
+}
+
+func TestValidatePropertiesSchemaError(t *testing.T) {
+	tests := []struct {
+		b      string
+		schema string
+	}{
+		{`{}`, `{`},
+		{`{`, `{}`},
+		{`{}`, `1`},
+		{`{}`, `{"type": 1}`},
+		{`{"s": "x"}`, `{"properties": {"s": {"pattern": "("}}}`},
+	}
+	for _, c := range tests {
+		err := ValidatePropertiesSchema([]byte(c.b), []byte(c.schema))
+		if err == nil {
+			t.Errorf("error expected for %s and %s", c.b, c.schema)
+		} else if errors.As(err, new(*ValidationError)) {
+			t.Errorf("got %v, want an error other than *ValidationError", err)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// The scalar types below scan a scalar result of a Cypher query, such as
+// count(v), avg(v.age), or v.name. AgensGraph returns such a result as a JSON
+// value in its text form (e.g. 3, 30.5, "go", or null), or as a SQL value for
+// some aggregate functions (e.g. bigint for count).
+//
+// Valid is false if the result is SQL NULL, which means there is no value,
+// or JSON null, which is a value. Null is true only for JSON null so that the
+// two can be distinguished; for example, v.name returns SQL NULL if v has no
+// property "name" but JSON null if the value of the property is null.
+
+// AgInt scans a scalar result as an integer. A JSON number that is not an
+// integer or does not fit in int64 is an error.
+type AgInt struct {
+	Int64 int64
+	Valid bool
+	Null  bool
+}
+
+// Scan implements the database/sql Scanner interface.
+func (n *AgInt) Scan(src interface{}) error {
+	*n = AgInt{}
+
+	b, ok, err := scalarSource(src, "integer")
+	if !ok {
+		return err
+	}
+	switch src := src.(type) {
+	case int64:
+		n.Int64, n.Valid = src, true
+		return nil
+	case float64:
+		if src != math.Trunc(src) || src < math.MinInt64 || src >= math.MaxInt64 {
+			return fmt.Errorf("invalid integer: %v", src)
+		}
+		n.Int64, n.Valid = int64(src), true
+		return nil
+	}
+	if isJSONNull(b) {
+		n.Null = true
+		return nil
+	}
+
+	i, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return errors.New("invalid integer: " + err.Error())
+	}
+	n.Int64, n.Valid = i, true
+	return nil
+}
+
+// AgFloat scans a scalar result as a floating-point number. It accepts
+// integers as well.
+type AgFloat struct {
+	Float64 float64
+	Valid   bool
+	Null    bool
+}
+
+// Scan implements the database/sql Scanner interface.
+func (n *AgFloat) Scan(src interface{}) error {
+	*n = AgFloat{}
+
+	b, ok, err := scalarSource(src, "number")
+	if !ok {
+		return err
+	}
+	switch src := src.(type) {
+	case int64:
+		n.Float64, n.Valid = float64(src), true
+		return nil
+	case float64:
+		n.Float64, n.Valid = src, true
+		return nil
+	}
+	if isJSONNull(b) {
+		n.Null = true
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return errors.New("invalid number: " + err.Error())
+	}
+	n.Float64, n.Valid = f, true
+	return nil
+}
+
+// AgString scans a scalar result as a string. The result must be a JSON
+// string (e.g. "go" including the quotes) or JSON null; use sql.NullString
+// for a result of SQL type text.
+type AgString struct {
+	String string
+	Valid  bool
+	Null   bool
+}
+
+// Scan implements the database/sql Scanner interface.
+func (s *AgString) Scan(src interface{}) error {
+	*s = AgString{}
+
+	b, ok, err := scalarSource(src, "string")
+	if !ok {
+		return err
+	}
+	if b == nil {
+		return fmt.Errorf("invalid source for string: %T", src)
+	}
+	if isJSONNull(b) {
+		s.Null = true
+		return nil
+	}
+
+	if len(b) < 1 || b[0] != '"' {
+		return fmt.Errorf("invalid string: %s", b)
+	}
+	err = json.Unmarshal(b, &s.String)
+	if err != nil {
+		return errors.New("invalid string: " + err.Error())
+	}
+	s.Valid = true
+	return nil
+}
+
+// ScanScalarArray reads a JSON array of scalars from src, such as the result
+// of collect(v.name), and stores the result in out. out must be a pointer to a
+// slice whose element type is string, bool, an integer type, or a
+// floating-point type, or a pointer to one of them. A JSON null element
+// becomes nil for pointer element types and is an error otherwise.
+//
+// Each element must be of the type out requires. A number is an integer only
+// if it has no fraction and fits in the element type. If src is nil, out is
+// set to nil.
+func ScanScalarArray(src interface{}, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%T is not a non-nil pointer to slice", out)
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	if src == nil {
+		rv.Set(reflect.Zero(rt))
+		return nil
+	}
+
+	b, ok := textSource(src)
+	if !ok {
+		return fmt.Errorf("invalid source for %s: %T", rt, src)
+	}
+
+	var raw []interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	err := d.Decode(&raw)
+	if err != nil {
+		return errors.New("invalid array: " + err.Error())
+	}
+	if raw == nil {
+		return fmt.Errorf("invalid source for %s: %s", rt, b)
+	}
+
+	s := reflect.MakeSlice(rt, len(raw), len(raw))
+	for i, x := range raw {
+		err = setScalar(s.Index(i), x)
+		if err != nil {
+			return fmt.Errorf("invalid element %d: %v", i, err)
+		}
+	}
+	rv.Set(s)
+	return nil
+}
+
+// setScalar sets v to the JSON scalar x.
+func setScalar(v reflect.Value, x interface{}) error {
+	if v.Kind() == reflect.Ptr {
+		if x == nil {
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	switch x := x.(type) {
+	case nil:
+		return fmt.Errorf("null for %s", v.Type())
+	case string:
+		if v.Kind() == reflect.String {
+			v.SetString(x)
+			return nil
+		}
+	case bool:
+		if v.Kind() == reflect.Bool {
+			v.SetBool(x)
+			return nil
+		}
+	case json.Number:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, err := strconv.ParseInt(string(x), 10, v.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("%s is not %s", x, v.Type())
+			}
+			v.SetInt(i)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u, err := strconv.ParseUint(string(x), 10, v.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("%s is not %s", x, v.Type())
+			}
+			v.SetUint(u)
+			return nil
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(string(x), v.Type().Bits())
+			if err != nil {
+				return fmt.Errorf("%s is not %s", x, v.Type())
+			}
+			v.SetFloat(f)
+			return nil
+		}
+	default:
+		return fmt.Errorf("%T is not a scalar", x)
+	}
+	return fmt.Errorf("%v is not %s", x, v.Type())
+}
+
+// scalarSource returns the text of src for a scalar of the type named name.
+// b is nil if src is int64 or float64. ok is false if src is SQL NULL, in
+// which case err is nil, or src is invalid.
+func scalarSource(src interface{}, name string) (b []byte, ok bool, err error) {
+	switch src.(type) {
+	case nil:
+		return nil, false, nil
+	case int64, float64:
+		return nil, true, nil
+	}
+
+	b, ok = textSource(src)
+	if !ok {
+		return nil, false, fmt.Errorf("invalid source for %s: %T", name, src)
+	}
+	if len(b) < 1 {
+		return nil, false, fmt.Errorf("invalid source for %s: %v", name, b)
+	}
+	return b, true, nil
+}
+
+func isJSONNull(b []byte) bool {
+	return string(b) == "null"
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+func TestAgIntScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want AgInt
+	}{
+		{nil, AgInt{}},
+		{[]byte("null"), AgInt{Null: true}},
+		{[]byte("42"), AgInt{42, true, false}},
+		{[]byte("-9223372036854775808"), AgInt{-9223372036854775808, true, false}},
+		{"7", AgInt{7, true, false}},
+		{int64(3), AgInt{3, true, false}},
+		{float64(3), AgInt{3, true, false}},
+	}
+	for _, c := range tests {
+		n := AgInt{Int64: 1, Valid: true, Null: true}
+		err := n.Scan(c.src)
+		if err != nil {
+			t.Errorf("%v: %v", c.src, err)
+		} else if n != c.want {
+			t.Errorf("got %+v, want %+v", n, c.want)
+		}
+	}
+
+	for _, src := range []interface{}{[]byte("1.5"), []byte("9223372036854775808"), []byte(`"1"`), []byte(""), float64(1.5), true} {
+		var n AgInt
+		if err := n.Scan(src); err == nil {
+			t.Errorf("error expected for %v", src)
+		}
+	}
+}
+
+func TestAgFloatScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want AgFloat
+	}{
+		{nil, AgFloat{}},
+		{[]byte("null"), AgFloat{Null: true}},
+		{[]byte("30.5"), AgFloat{30.5, true, false}},
+		{[]byte("1e3"), AgFloat{1000, true, false}},
+		{[]byte("30.5000000000000000"), AgFloat{30.5, true, false}},
+		{int64(3), AgFloat{3, true, false}},
+		{float64(0.25), AgFloat{0.25, true, false}},
+	}
+	for _, c := range tests {
+		var n AgFloat
+		err := n.Scan(c.src)
+		if err != nil {
+			t.Errorf("%v: %v", c.src, err)
+		} else if n != c.want {
+			t.Errorf("got %+v, want %+v", n, c.want)
+		}
+	}
+
+	for _, src := range []interface{}{[]byte(`"1"`), []byte("x"), true} {
+		var n AgFloat
+		if err := n.Scan(src); err == nil {
+			t.Errorf("error expected for %v", src)
+		}
+	}
+}
+
+func TestAgStringScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want AgString
+	}{
+		{nil, AgString{}},
+		{[]byte("null"), AgString{Null: true}},
+		{[]byte(`"null"`), AgString{"null", true, false}},
+		{[]byte(`"go\né"`), AgString{"go\né", true, false}},
+		{`""`, AgString{"", true, false}},
+	}
+	for _, c := range tests {
+		var s AgString
+		err := s.Scan(c.src)
+		if err != nil {
+			t.Errorf("%v: %v", c.src, err)
+		} else if s != c.want {
+			t.Errorf("got %+v, want %+v", s, c.want)
+		}
+	}
+
+	for _, src := range []interface{}{[]byte("go"), []byte("1"), []byte(`"go`), int64(1)} {
+		var s AgString
+		if err := s.Scan(src); err == nil {
+			t.Errorf("error expected for %v", src)
+		}
+	}
+}
+
+func TestAgScalarRows(t *testing.T) {
+	db := openTestDB([]string{"count", "avg", "name"},
+		[]driver.Value{int64(3), []byte("30.5"), []byte(`"go"`)},
+		[]driver.Value{int64(0), nil, []byte("null")},
+	)
+	defer db.Close()
+
+	rows, err := db.Query("MATCH (v) RETURN count(v), avg(v.age), v.name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var n AgInt
+		var f AgFloat
+		var s AgString
+		err = rows.Scan(&n, &f, &s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%v %v %v", n, f, s))
+	}
+	want := []string{"{3 true false} {30.5 true false} {go true false}", "{0 true false} {0 false false} { false true}"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got[i], want[i])
+		}
+	}
+}
+
+func TestScanScalarArray(t *testing.T) {
+	var ss []string
+	err := ScanScalarArray([]byte(`["a", "b"]`), &ss)
+	if err != nil || fmt.Sprint(ss) != "[a b]" {
+		t.Errorf("got %v, %v, want [a b]", ss, err)
+	}
+
+	var is []int64
+	err = ScanScalarArray(`[1, -2, 9007199254740993]`, &is)
+	if err != nil || fmt.Sprint(is) != "[1 -2 9007199254740993]" {
+		t.Errorf("got %v, %v, want [1 -2 9007199254740993]", is, err)
+	}
+
+	var fs []float64
+	err = ScanScalarArray([]byte(`[1, 2.5]`), &fs)
+	if err != nil || fmt.Sprint(fs) != "[1 2.5]" {
+		t.Errorf("got %v, %v, want [1 2.5]", fs, err)
+	}
+
+	var bs []bool
+	err = ScanScalarArray([]byte(`[]`), &bs)
+	if err != nil || bs == nil || len(bs) != 0 {
+		t.Errorf("got %v, %v, want []", bs, err)
+	}
+
+	var ps []*string
+	err = ScanScalarArray([]byte(`["a", null]`), &ps)
+	if err != nil || len(ps) != 2 || *ps[0] != "a" || ps[1] != nil {
+		t.Errorf("got %v, %v, want [a nil]", ps, err)
+	}
+
+	err = ScanScalarArray(nil, &ss)
+	if err != nil || ss != nil {
+		t.Errorf("got %v, %v, want nil", ss, err)
+	}
+}
+
+func TestScanScalarArrayError(t *testing.T) {
+	var ss []string
+	var is []int64
+	var i8s []int8
+	var us []uint
+	tests := []struct {
+		src interface{}
+		out interface{}
+	}{
+		{[]byte(`["a", 1]`), &ss},
+		{[]byte(`["a", null]`), &ss},
+		{[]byte(`[1.5]`), &is},
+		{[]byte(`[128]`), &i8s},
+		{[]byte(`[-1]`), &us},
+		{[]byte(`[[1]]`), &is},
+		{[]byte(`{"a": 1}`), &is},
+		{[]byte(`null`), &is},
+		{[]byte(`[1`), &is},
+		{1, &is},
+		{[]byte(`[1]`), is},
+		{[]byte(`[1]`), (*[]int64)(nil)},
+	}
+	for _, c := range tests {
+		err := ScanScalarArray(c.src, c.out)
+		if err == nil {
+			t.Errorf("error expected for %v and %T", c.src, c.out)
+		}
+	}
+}
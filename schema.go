@@ -0,0 +1,116 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// GraphSchema describes the labels of a graph.
+type GraphSchema struct {
+	Name         string
+	VertexLabels []LabelSchema
+	EdgeLabels   []LabelSchema
+}
+
+// LabelSchema describes a label of a graph.
+type LabelSchema struct {
+	Name string
+	// Id is the label ID, which is the first part of GraphId of the
+	// vertices or edges that have the label.
+	Id uint16
+}
+
+const describeGraphQuery = `SELECT l.labname, l.labid, l.labkind
+FROM ag_catalog.ag_label l
+JOIN ag_catalog.ag_graph g ON g.oid = l.graphid
+WHERE g.graphname = $1
+ORDER BY l.labid`
+
+// DescribeGraph reads the labels of the graph named graphName from the
+// ag_graph and ag_label catalogs. The labels are ordered by their IDs and
+// include the base labels ag_vertex and ag_edge.
+//
+// An error will be returned if there is no such graph.
+func DescribeGraph(ctx context.Context, db *sql.DB, graphName string) (GraphSchema, error) {
+	rows, err := db.QueryContext(ctx, describeGraphQuery, graphName)
+	if err != nil {
+		return GraphSchema{}, err
+	}
+	defer rows.Close()
+
+	s := GraphSchema{Name: graphName}
+	found := false
+	for rows.Next() {
+		found = true
+
+		var l LabelSchema
+		var kind string
+		err = rows.Scan(&l.Name, &l.Id, &kind)
+		if err != nil {
+			return GraphSchema{}, err
+		}
+
+		switch kind {
+		case "v":
+			s.VertexLabels = append(s.VertexLabels, l)
+		case "e":
+			s.EdgeLabels = append(s.EdgeLabels, l)
+		default:
+			return GraphSchema{}, fmt.Errorf("invalid kind of label %q: %q", l.Name, kind)
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return GraphSchema{}, err
+	}
+	if !found {
+		return GraphSchema{}, fmt.Errorf("graph %q does not exist", graphName)
+	}
+
+	return s, nil
+}
+
+// LabelCache maps label IDs to label names. It implements GraphIdFormatter
+// and can be passed to (GraphId).Format to display GraphId as "label:local"
+// (e.g. "person:1").
+type LabelCache map[uint16]string
+
+// NewLabelCache returns LabelCache of the vertex and edge labels in s.
+func NewLabelCache(s GraphSchema) LabelCache {
+	c := make(LabelCache, len(s.VertexLabels)+len(s.EdgeLabels))
+	for _, l := range s.VertexLabels {
+		c[l.Id] = l.Name
+	}
+	for _, l := range s.EdgeLabels {
+		c[l.Id] = l.Name
+	}
+	return c
+}
+
+// FormatGraphId implements GraphIdFormatter interface. It returns the text
+// form of gid if the label ID of gid is not in c.
+func (c LabelCache) FormatGraphId(gid GraphId) string {
+	name, ok := c[gid.LabelId()]
+	if !ok {
+		return gid.String()
+	}
+	return name + ":" + gid.s[strings.IndexByte(gid.s, '.')+1:]
+}
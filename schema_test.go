@@ -0,0 +1,132 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+func TestDescribeGraph(t *testing.T) {
+	db := openTestDB([]string{"labname", "labid", "labkind"},
+		[]driver.Value{[]byte("ag_vertex"), int64(1), []byte("v")},
+		[]driver.Value{[]byte("ag_edge"), int64(2), []byte("e")},
+		[]driver.Value{[]byte("person"), int64(3), []byte("v")},
+		[]driver.Value{[]byte("knows"), int64(4), []byte("e")},
+	)
+	defer db.Close()
+
+	s, err := DescribeGraph(context.Background(), db, "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Name != "g" {
+		t.Errorf(`got %q, want "g"`, s.Name)
+	}
+	if want := []LabelSchema{{"ag_vertex", 1}, {"person", 3}}; !equalLabelSchemas(s.VertexLabels, want) {
+		t.Errorf("got %v, want %v", s.VertexLabels, want)
+	}
+	if want := []LabelSchema{{"ag_edge", 2}, {"knows", 4}}; !equalLabelSchemas(s.EdgeLabels, want) {
+		t.Errorf("got %v, want %v", s.EdgeLabels, want)
+	}
+}
+
+func equalLabelSchemas(x, y []LabelSchema) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDescribeGraphError(t *testing.T) {
+	tests := [][][]driver.Value{
+		nil,
+		{{[]byte("person"), int64(3), []byte("x")}},
+	}
+	for _, rows := range tests {
+		db := openTestDB([]string{"labname", "labid", "labkind"}, rows...)
+		_, err := DescribeGraph(context.Background(), db, "g")
+		if err == nil {
+			t.Errorf("error expected for %v", rows)
+		}
+		db.Close()
+	}
+}
+
+func TestLabelCache(t *testing.T) {
+	c := NewLabelCache(GraphSchema{
+		VertexLabels: []LabelSchema{{"person", 3}},
+		EdgeLabels:   []LabelSchema{{"knows", 4}},
+	})
+
+	tests := []struct {
+		gid  GraphId
+		want string
+	}{
+		{mustNewGraphId("3.1"), "person:1"},
+		{mustNewGraphId("4.12"), "knows:12"},
+		{mustNewGraphId("5.1"), "5.1"},
+		{mustNewGraphId("NULL"), "NULL"},
+	}
+	for _, tc := range tests {
+		if s := tc.gid.Format(c); s != tc.want {
+			t.Errorf("got %q, want %q", s, tc.want)
+		}
+	}
+
+	// String is not affected by the formatter.
+	if s := mustNewGraphId("3.1").String(); s != "3.1" {
+		t.Errorf(`got %q, want "3.1"`, s)
+	}
+	if s := mustNewGraphId("3.1").Format(nil); s != "3.1" {
+		t.Errorf(`got %q, want "3.1"`, s)
+	}
+}
+
+func TestServerDescribeGraph(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	db := mustOpenAndSetGraph(t)
+	defer db.Close()
+
+	_, err := db.Exec(`CREATE (:sv)-[:se]->(:sv)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := DescribeGraph(context.Background(), db, agTestGraphName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, l := range s.VertexLabels {
+		if l.Name == "sv" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("got %v, want vertex label sv", s.VertexLabels)
+	}
+}
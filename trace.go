@@ -0,0 +1,102 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import "fmt"
+
+// Tracer starts a span for a call of ScanEntity, ScanEntityN, ScanEntities,
+// ScanEntitiesPartial, or ScanPath, and the methods of Decoder. It has no
+// dependency on any tracing library; adapt the tracer of the library to it.
+// For example, with OpenTelemetry:
+//
+//	type otelTracer struct {
+//		ctx    context.Context
+//		tracer trace.Tracer
+//	}
+//
+//	func (t otelTracer) Start(name string) ag.Span {
+//		_, span := t.tracer.Start(t.ctx, name)
+//		return otelSpan{span}
+//	}
+//
+//	type otelSpan struct {
+//		span trace.Span
+//	}
+//
+//	func (s otelSpan) SetAttribute(key string, value interface{}) {
+//		s.span.SetAttributes(attribute.String(key, fmt.Sprint(value)))
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//		s.span.RecordError(err)
+//		s.span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() { s.span.End() }
+//
+// Since the calls take no context, the adapter decides the parent of the
+// spans, such as ctx above.
+type Tracer interface {
+	// Start starts a span named name, such as "ag.ScanEntity".
+	Start(name string) Span
+}
+
+// Span is a span started by Tracer.
+type Span interface {
+	// SetAttribute is called with "ag.type", the Go type that the call
+	// stores the result in, and "ag.bytes", the length of the source, if
+	// the source is []byte or string.
+	SetAttribute(key string, value interface{})
+	// RecordError is called with the error of the call if it fails.
+	RecordError(err error)
+	// End is called when the call returns.
+	End()
+}
+
+// Trace makes ScanEntity, ScanEntityN, ScanEntities, ScanEntitiesPartial, and
+// ScanPath start a span with t for every call. The elements of arrays and
+// paths do not have spans of their own.
+func Trace(t Tracer) ScanOption {
+	return func(o *scanOptions) {
+		o.tracer = t
+	}
+}
+
+// traced calls f in a span named name if the options have a Tracer. src is
+// the source and dst is where the result is stored.
+func (o scanOptions) traced(name string, src interface{}, dst interface{}, f func() error) error {
+	if o.tracer == nil {
+		return f()
+	}
+
+	s := o.tracer.Start(name)
+	defer s.End()
+
+	s.SetAttribute("ag.type", fmt.Sprintf("%T", dst))
+	switch src := src.(type) {
+	case []byte:
+		s.SetAttribute("ag.bytes", len(src))
+	case string:
+		s.SetAttribute("ag.bytes", len(src))
+	}
+
+	err := f()
+	if err != nil {
+		s.RecordError(err)
+	}
+	return err
+}
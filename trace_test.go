@@ -0,0 +1,95 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(name string) Span {
+	s := &testSpan{name: name, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+func TestTrace(t *testing.T) {
+	var tr testTracer
+	dec := NewDecoder(Trace(&tr))
+
+	var v BasicVertex
+	if err := dec.ScanEntity([]byte(`v[3.1]{}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	var vs []BasicVertex
+	if err := dec.ScanEntities(`[v[3.1]{},v[3.2]{}]`, &vs); err != nil {
+		t.Fatal(err)
+	}
+	var p BasicPath
+	if err := ScanPath([]byte(`[v[3.1]{}]`), &p, Trace(&tr)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.ScanEntityN([]byte(`v[3.1]{"a"}`), &v); err == nil {
+		t.Fatal("error expected for bad properties")
+	}
+	if err := dec.ScanEntity(nil, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []testSpan{
+		{"ag.ScanEntity", map[string]interface{}{"ag.type": "*ag.BasicVertex", "ag.bytes": 8}, nil, true},
+		{"ag.ScanEntities", map[string]interface{}{"ag.type": "*[]ag.BasicVertex", "ag.bytes": 19}, nil, true},
+		{"ag.ScanPath", map[string]interface{}{"ag.type": "*ag.BasicPath", "ag.bytes": 10}, nil, true},
+		{"ag.ScanEntityN", map[string]interface{}{"ag.type": "*ag.BasicVertex", "ag.bytes": 11}, nil, true},
+		{"ag.ScanEntity", map[string]interface{}{"ag.type": "*ag.BasicVertex"}, nil, true},
+	}
+	if len(tr.spans) != len(want) {
+		t.Fatalf("got %d spans, want %d", len(tr.spans), len(want))
+	}
+	for i, s := range tr.spans {
+		w := want[i]
+		if s.name != w.name || !reflect.DeepEqual(s.attrs, w.attrs) || !s.ended {
+			t.Errorf("got %+v, want %+v", *s, w)
+		}
+		if (s.err != nil) != (i == 3) {
+			t.Errorf("got error %v for %s", s.err, s.name)
+		}
+	}
+}
@@ -16,10 +16,17 @@ limitations under the License.
 
 package ag
 
-import "fmt"
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
 
 func readJSONObject(b []byte) ([]byte, error) {
-	if b[0] != byte('{') {
+	if len(b) < 1 || b[0] != byte('{') {
 		return nil, fmt.Errorf("invalid JSON object: %s", b)
 	}
 	depth := 1
@@ -57,3 +64,202 @@ func readJSONObject(b []byte) ([]byte, error) {
 
 	return nil, fmt.Errorf("invalid JSON object: %s", b)
 }
+
+// checkDuplicateKeys returns an error if any JSON object in b, including b
+// itself, has duplicate keys.
+func checkDuplicateKeys(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return checkDuplicateKeysValue(dec)
+}
+
+func checkDuplicateKeysValue(dec *json.Decoder) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		keys := make(map[string]struct{})
+		for dec.More() {
+			t, err = dec.Token()
+			if err != nil {
+				return err
+			}
+			k := t.(string)
+			if _, ok := keys[k]; ok {
+				return fmt.Errorf("duplicate key: %q", k)
+			}
+			keys[k] = struct{}{}
+
+			err = checkDuplicateKeysValue(dec)
+			if err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for dec.More() {
+			err = checkDuplicateKeysValue(dec)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	// closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+// textSource returns the text of src from the database driver. ok is false if
+// src is not a text.
+//
+// sql.RawBytes is copied since it is valid only until the next call to Scan.
+func textSource(src interface{}) (b []byte, ok bool) {
+	switch src := src.(type) {
+	case []byte:
+		return src, true
+	case string:
+		return []byte(src), true
+	case sql.RawBytes:
+		return append([]byte(nil), src...), true
+	case fmt.Stringer:
+		return []byte(src.String()), true
+	}
+
+	// types whose underlying type is []byte or string
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.String:
+		return []byte(rv.String()), true
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), true
+		}
+	}
+	return nil, false
+}
+
+// stripJSONExtensions returns b without "//" comments and trailing commas,
+// which are not allowed in JSON. Strings in b are left as they are.
+func stripJSONExtensions(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '"':
+			j := skipJSONString(b, i)
+			out = append(out, b[i:j]...)
+			i = j - 1
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			i--
+		case c == ',':
+			j := skipJSONSpace(b, i+1)
+			if j < len(b) && (b[j] == '}' || b[j] == ']') {
+				continue
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// lowercaseJSONKeys returns b with the keys of the object b, but not of the
+// nested objects, in lower case. Values are left as they are.
+func lowercaseJSONKeys(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	depth := 0
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; c {
+		case '"':
+			j := skipJSONString(b, i)
+			if k := skipJSONSpace(b, j); depth == 1 && k < len(b) && b[k] == ':' {
+				out = appendLowerJSONString(out, b[i:j])
+			} else {
+				out = append(out, b[i:j]...)
+			}
+			i = j - 1
+		case '{', '[':
+			depth++
+			out = append(out, c)
+		case '}', ']':
+			depth--
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// appendLowerJSONString appends the JSON string s in lower case to b.
+func appendLowerJSONString(b, s []byte) []byte {
+	if bytes.IndexByte(s, '\\') < 0 {
+		return append(b, bytes.ToLower(s)...)
+	}
+
+	// unescape to lowercase escaped characters as well
+	var str string
+	if err := json.Unmarshal(s, &str); err != nil {
+		return append(b, s...)
+	}
+	q, _ := json.Marshal(strings.ToLower(str))
+	return append(b, q...)
+}
+
+// copyTextEscaper escapes the characters that are special in the text format
+// of COPY.
+var copyTextEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// marshalCopyProperties returns properties m as a column of the text format
+// of COPY.
+func marshalCopyProperties(m map[string]interface{}) (string, error) {
+	if m == nil {
+		return "{}", nil
+	}
+
+	b, err := MarshalProperties(m)
+	if err != nil {
+		return "", err
+	}
+	return copyTextEscaper.Replace(string(b)), nil
+}
+
+// skipJSONString returns the index right after the string that begins at i.
+// It returns len(b) if the string is not terminated.
+func skipJSONString(b []byte, i int) int {
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(b)
+}
+
+// skipJSONSpace returns the index of the first byte from i that is neither
+// whitespace nor in a "//" comment.
+func skipJSONSpace(b []byte, i int) int {
+	for i < len(b) {
+		switch {
+		case b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r':
+			i++
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
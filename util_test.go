@@ -0,0 +1,85 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type testText string
+
+type testStringer struct {
+	s string
+}
+
+func (s testStringer) String() string {
+	return s.s
+}
+
+func TestTextSource(t *testing.T) {
+	tests := []interface{}{
+		[]byte("v[3.1]{}"),
+		"v[3.1]{}",
+		sql.RawBytes("v[3.1]{}"),
+		testText("v[3.1]{}"),
+		testStringer{"v[3.1]{}"},
+	}
+	for _, src := range tests {
+		b, ok := textSource(src)
+		if !ok {
+			t.Errorf("got false, want true for %T", src)
+		} else if string(b) != "v[3.1]{}" {
+			t.Errorf("got %s, want v[3.1]{}", b)
+		}
+	}
+
+	for _, src := range []interface{}{nil, 0, []int{1}, 1.5} {
+		if _, ok := textSource(src); ok {
+			t.Errorf("got true, want false for %T", src)
+		}
+	}
+}
+
+func TestTextSourceRawBytes(t *testing.T) {
+	src := sql.RawBytes("v[3.1]{}")
+	b, _ := textSource(src)
+	if &b[0] == &src[0] {
+		t.Error("text references underlying array of sql.RawBytes")
+	}
+}
+
+func TestStripJSONExtensions(t *testing.T) {
+	tests := []struct {
+		b    string
+		want string
+	}{
+		{`{"a": 1}`, `{"a": 1}`},
+		{`{"a": 1,}`, `{"a": 1}`},
+		{`{"a": [1, 2, ], }`, `{"a": [1, 2 ] }`},
+		{"{\"a\": 1, // one\n}", "{\"a\": 1 \n}"},
+		{"{// comment, with \"quote\"\n\"a\": 1}", "{\n\"a\": 1}"},
+		{`{"a": "x, }", "b": "// no comment",}`, `{"a": "x, }", "b": "// no comment"}`},
+		{`{"a": "\\", "b": "\","}`, `{"a": "\\", "b": "\","}`},
+		{`{"a": 1} // end`, `{"a": 1} `},
+	}
+	for _, c := range tests {
+		if got := string(stripJSONExtensions([]byte(c.b))); got != c.want {
+			t.Errorf("got %q, want %q", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// AgValue can be used to scan a JSON document that mixes vertices, edges,
+// and scalars, such as the result of row_to_json or a Cypher map or list
+// literal of entities. Value is the decoded document.
+//
+// The document is decoded recursively by the following rules.
+//
+//   - An object that has "label", "id", and "properties", whose "id" is a
+//     graphid, becomes BasicVertex. If it also has "start" and "end", it
+//     becomes BasicEdge. This is the same object that NestedEntity and
+//     ScanPath read, and the properties are decoded as BasicVertex and
+//     BasicEdge decode them.
+//   - Any other object becomes map[string]interface{}, and an array becomes
+//     []interface{}.
+//   - A number becomes float64, as BasicVertex and BasicEdge decode numbers
+//     in properties.
+//   - A string, a boolean, and null become string, bool, and nil.
+//
+// Valid is false if the value from the database driver is SQL NULL. JSON null
+// makes Valid true and Value nil.
+type AgValue struct {
+	Value interface{}
+	Valid bool
+}
+
+// Scan implements the database/sql Scanner interface.
+func (v *AgValue) Scan(src interface{}) error {
+	*v = AgValue{}
+	if src == nil {
+		return nil
+	}
+
+	b, ok := textSource(src)
+	if !ok {
+		return fmt.Errorf("invalid source for value: %T", src)
+	}
+
+	var raw json.RawMessage
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return errors.New("invalid value: " + err.Error())
+	}
+
+	val, err := decodeAgValue(raw)
+	if err != nil {
+		return err
+	}
+	v.Value, v.Valid = val, true
+	return nil
+}
+
+// decodeAgValue decodes b, which is valid JSON.
+func decodeAgValue(b json.RawMessage) (interface{}, error) {
+	if len(b) < 1 {
+		return nil, errors.New("invalid value: empty")
+	}
+
+	switch b[0] {
+	case '[':
+		var raws []json.RawMessage
+		err := json.Unmarshal(b, &raws)
+		if err != nil {
+			return nil, errors.New("invalid value: " + err.Error())
+		}
+		a := make([]interface{}, len(raws))
+		for i, x := range raws {
+			a[i], err = decodeAgValue(x)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case '{':
+		if e, ok, err := decodeAgEntity(b); ok {
+			return e, err
+		}
+		var raws map[string]json.RawMessage
+		err := json.Unmarshal(b, &raws)
+		if err != nil {
+			return nil, errors.New("invalid value: " + err.Error())
+		}
+		m := make(map[string]interface{}, len(raws))
+		for k, x := range raws {
+			m[k], err = decodeAgValue(x)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	default:
+		var val interface{}
+		err := json.Unmarshal(b, &val)
+		if err != nil {
+			return nil, errors.New("invalid value: " + err.Error())
+		}
+		return val, nil
+	}
+}
+
+// decodeAgEntity decodes b as BasicVertex or BasicEdge. ok is false if b is
+// not an entity.
+func decodeAgEntity(b []byte) (e interface{}, ok bool, err error) {
+	var je jsonEntity
+	if json.Unmarshal(b, &je) != nil {
+		return nil, false, nil
+	}
+	d, ok, err := je.entityData(scanOptions{})
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+
+	if _, isEdge := d.core.(EdgeCore); isEdge {
+		var ed BasicEdge
+		err = ScanEntity(d, &ed)
+		return ed, true, err
+	}
+	var v BasicVertex
+	err = ScanEntity(d, &v)
+	return v, true, err
+}
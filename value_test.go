@@ -0,0 +1,135 @@
+/*
+Copyright 2025 SKAI Worldwide Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAgValueScan(t *testing.T) {
+	src := []byte(`{
+		"person": {"label": "person", "id": "3.1", "properties": {"name": "go", "age": 15}},
+		"knows": {"label": "knows", "id": "4.1", "start": "3.1", "end": "3.2", "properties": {}},
+		"count": 2,
+		"avg": 30.5,
+		"tags": ["a", 1, null, true],
+		"meta": {"label": "x"}
+	}`)
+
+	var v AgValue
+	err := v.Scan(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Valid {
+		t.Fatal("got invalid, want valid")
+	}
+	m, ok := v.Value.(map[string]interface{})
+	if !ok {
+		t.Fatalf("got %T, want map[string]interface{}", v.Value)
+	}
+
+	p, ok := m["person"].(BasicVertex)
+	if !ok {
+		t.Errorf("got %T, want BasicVertex", m["person"])
+	} else if p.Label != "person" || p.Id.String() != "3.1" || p.Properties["name"] != "go" || p.Properties["age"] != float64(15) {
+		t.Errorf("got %s, want person[3.1]", p)
+	}
+
+	k, ok := m["knows"].(BasicEdge)
+	if !ok {
+		t.Errorf("got %T, want BasicEdge", m["knows"])
+	} else if k.Id.String() != "4.1" || k.Start.String() != "3.1" || k.End.String() != "3.2" {
+		t.Errorf("got %s, want knows[4.1][3.1,3.2]", k)
+	}
+
+	if m["count"] != float64(2) {
+		t.Errorf("got %#v, want float64(2)", m["count"])
+	}
+	if m["avg"] != 30.5 {
+		t.Errorf("got %#v, want 30.5", m["avg"])
+	}
+	if want := []interface{}{"a", float64(1), nil, true}; !reflect.DeepEqual(m["tags"], want) {
+		t.Errorf("got %#v, want %#v", m["tags"], want)
+	}
+	if want := map[string]interface{}{"label": "x"}; !reflect.DeepEqual(m["meta"], want) {
+		t.Errorf("got %#v, want %#v", m["meta"], want)
+	}
+}
+
+func TestAgValueScanEntityProperties(t *testing.T) {
+	var v AgValue
+	err := v.Scan([]byte(`[
+		{"label": "v", "id": "3.1", "properties": {"n": 1, "f": 1.5, "a": [2]}},
+		{"label": "e", "id": "4.1", "start": "3.1", "end": "3.2", "properties": {"w": 3}},
+		{"label": "v", "id": "3.2", "properties": {}}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := v.Value.([]interface{})
+
+	var want BasicVertex
+	err = ScanEntity(`v[3.1]{"n": 1, "f": 1.5, "a": [2]}`, &want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := vals[0].(BasicVertex); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	p := BasicPath{Valid: true, Vertices: []BasicVertex{vals[0].(BasicVertex), vals[2].(BasicVertex)}, Edges: []BasicEdge{vals[1].(BasicEdge)}}
+	var q BasicPath
+	err = q.Scan(`[v[3.1]{"n": 1, "f": 1.5, "a": [2]},e[4.1][3.1,3.2]{"w": 3},v[3.2]{}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Equal(q) {
+		t.Errorf("got %s, want %s", p, q)
+	}
+	if w, err := p.TotalWeight("w"); err != nil || w != 3 {
+		t.Errorf("got %v, %v, want 3", w, err)
+	}
+}
+
+func TestAgValueScanNull(t *testing.T) {
+	var v AgValue
+	err := v.Scan(nil)
+	if err != nil || v.Valid {
+		t.Errorf("got %+v, %v, want SQL NULL", v, err)
+	}
+
+	err = v.Scan([]byte("null"))
+	if err != nil || !v.Valid || v.Value != nil {
+		t.Errorf("got %+v, %v, want JSON null", v, err)
+	}
+}
+
+func TestAgValueScanError(t *testing.T) {
+	tests := []interface{}{
+		[]byte(`{`),
+		[]byte(`{"label": "e", "id": "4.1", "start": "x", "end": "3.2", "properties": {}}`),
+		1,
+	}
+	for _, src := range tests {
+		var v AgValue
+		if err := v.Scan(src); err == nil {
+			t.Errorf("error expected for %v", src)
+		}
+	}
+}
@@ -17,12 +17,12 @@ limitations under the License.
 package ag
 
 import (
-	"bytes"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 // Vertex gives any struct an ability to read the value from the database
@@ -30,51 +30,66 @@ import (
 type Vertex struct{}
 
 // VertexCore represents essential data to identify a vertex.
+//
+// A vertex has exactly one label in the text form. A vertex label may inherit
+// other labels in AgensGraph, but the text form only has the label the vertex
+// was created with, so the inherited labels are not available here; query
+// them from the ag_label catalog (see DescribeGraph) if needed.
 type VertexCore struct {
 	Label string
 	Id    GraphId
 }
 
-var vertexCoreRegexp = regexp.MustCompile(`^(.+?)\[(\d+\.\d+)\]`)
+// Whitespace is allowed between the components and before the properties.
+var vertexCoreRegexp = regexp.MustCompile(`^(.+?)\s*\[\s*(\d+\.\d+)\s*\]\s*`)
 
-func (_ Vertex) readEntity(b []byte) (*entityData, error) {
+func (_ Vertex) readEntity(b []byte, o scanOptions) (*entityData, error) {
 	m := vertexCoreRegexp.FindSubmatch(b)
 	if m == nil {
 		return nil, fmt.Errorf("bad vertex representation: %s", b)
 	}
 
-	return makeVertexData(m[1], m[2], b[len(m[0]):])
+	return makeVertexData(m[1], m[2], b[len(m[0]):], o)
 }
 
-func makeVertexData(label, id, props []byte) (*entityData, error) {
+func makeVertexData(label, id, props []byte, o scanOptions) (*entityData, error) {
 	var c VertexCore
 
-	c.Label = string(label)
+	c.Label = o.label(label)
 
 	err := c.Id.Scan(id)
 	if err != nil {
 		return nil, errors.New("invalid vertex ID: " + err.Error())
 	}
 
-	return &entityData{c, props}, nil
+	return &entityData{core: c, properties: props}, nil
+}
+
+func (_ Vertex) readElement(b []byte, o scanOptions) (int, *entityData, error) {
+	return readVertexElement(b, o)
 }
 
-func (_ Vertex) readElements(b []byte) ([]interface{}, error) {
-	return readVertexElements(b)
+func (_ Vertex) readElements(b []byte, o scanOptions) ([]interface{}, error) {
+	return readVertexElements(b, o)
 }
 
-func readVertexElements(b []byte) ([]interface{}, error) {
-	// remove surrounding brackets
-	b = b[1 : len(b)-1]
+func readVertexElements(b []byte, o scanOptions) ([]interface{}, error) {
+	b, err := trimBrackets(b, "vertex")
+	if err != nil {
+		return nil, err
+	}
 
 	var ds []interface{}
 	for len(b) > 0 {
 		if len(ds) > 0 {
+			if b[0] != ',' {
+				return nil, fmt.Errorf("bad vertex array representation: %s", b)
+			}
 			// remove comma
 			b = b[1:]
 		}
 
-		advance, data, err := readVertexElement(b)
+		advance, data, err := readVertexElement(b, o)
 		if err != nil {
 			return nil, err
 		}
@@ -90,8 +105,8 @@ func readVertexElements(b []byte) ([]interface{}, error) {
 	return ds, nil
 }
 
-func readVertexElement(b []byte) (advance int, data *entityData, err error) {
-	if bytes.HasPrefix(b, nullElementValue) {
+func readVertexElement(b []byte, o scanOptions) (advance int, data *entityData, err error) {
+	if hasNullPrefix(b) {
 		advance = len(nullElementValue)
 		return
 	}
@@ -110,7 +125,7 @@ func readVertexElement(b []byte) (advance int, data *entityData, err error) {
 	}
 	advance += len(props)
 
-	data, err = makeVertexData(m[1], m[2], props)
+	data, err = makeVertexData(m[1], m[2], props, o)
 	return
 }
 
@@ -145,18 +160,42 @@ func (h *VertexHeader) SaveEntity(valid bool, core interface{}) error {
 // This is a reference implementation of an entity for vertex using all the
 // basic building blocks(Vertex, VertexCore, VertexHeader, EntitySaver,
 // PropertiesSaver, and ScanEntity.)
+//
+// BasicVertex implements encoding.TextMarshaler and json.Marshaler. A struct
+// that embeds BasicVertex gets these methods too, so encoding/json encodes it
+// as the vertex alone and drops its other fields. To keep them, hold
+// BasicVertex in a named field, or embed VertexHeader and declare Properties
+// instead.
 type BasicVertex struct {
 	VertexHeader
 	Properties map[string]interface{}
 }
 
 func (v BasicVertex) String() string {
-	if v.Valid {
-		p, _ := json.Marshal(v.Properties)
-		return fmt.Sprintf("%s[%s]%s", v.Label, v.Id, p)
-	} else {
-		return "NULL"
+	var b strings.Builder
+	v.writeString(&b)
+	return b.String()
+}
+
+// writeString writes the text form of v, which String returns, to b. If the
+// properties cannot be marshaled, it writes the text form without them and
+// returns the error.
+func (v BasicVertex) writeString(b *strings.Builder) error {
+	if !v.Valid {
+		b.Write(nullElementValue)
+		return nil
+	}
+
+	p, err := MarshalProperties(v.Properties)
+	b.WriteString(v.Label)
+	b.WriteByte('[')
+	b.WriteString(v.Id.String())
+	b.WriteByte(']')
+	b.Write(p)
+	if err != nil {
+		return errors.New("invalid vertex properties: " + err.Error())
 	}
+	return nil
 }
 
 // SaveProperties implements PropertiesSaver interface. It calls json.Unmarshal
@@ -169,6 +208,136 @@ func (v *BasicVertex) SaveProperties(b []byte) error {
 	return nil
 }
 
+func (v *BasicVertex) setProperties(m map[string]interface{}) {
+	v.Properties = m
+}
+
+// Into stores the properties of v in dst as json.Unmarshal does, so that the
+// type of the properties can be decided after v is scanned, for example by
+// the label of v. dst must be a non-nil pointer.
+//
+// Into marshals the properties back to JSON before unmarshaling them. Scan
+// into an entity of the type directly where performance matters.
+func (v BasicVertex) Into(dst interface{}) error {
+	b, err := MarshalProperties(v.Properties)
+	if err != nil {
+		return errors.New("invalid vertex properties: " + err.Error())
+	}
+	return json.Unmarshal(b, dst)
+}
+
+// Equal reports whether v and x have the same label, ID, and properties. Two
+// NULL vertices are equal. Properties are compared as PropertiesEqualIgnoring
+// does, so nil properties equal empty properties.
+func (v BasicVertex) Equal(x BasicVertex) bool {
+	if !v.Valid || !x.Valid {
+		return v.Valid == x.Valid
+	}
+	return v.VertexCore == x.VertexCore && PropertiesEqualIgnoring(v.Properties, x.Properties)
+}
+
+// ScanVertexProps reads a vertex from src and stores its properties in dst as
+// json.Unmarshal does, ignoring the label and the ID. dst may be any non-nil
+// pointer, including a pointer to an anonymous struct, so that one-off queries
+// don't need an entity type. dst is left unchanged if src is NULL.
+func ScanVertexProps(src interface{}, dst interface{}) error {
+	return ScanEntity(src, &propsVertex{dst: dst})
+}
+
+// propsVertex is an entity for vertex that stores the properties in dst.
+type propsVertex struct {
+	VertexHeader
+	dst interface{}
+}
+
+func (v *propsVertex) SaveProperties(b []byte) error {
+	err := json.Unmarshal(b, v.dst)
+	if err != nil {
+		return errors.New("invalid vertex properties: " + err.Error())
+	}
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. It returns the
+// same text form as String does.
+func (v BasicVertex) MarshalText() ([]byte, error) {
+	var b strings.Builder
+	err := v.writeString(&b)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+// CopyLine returns v as a line, without the newline, in the text format of
+// COPY for the table of the label of v, whose columns are id and properties:
+//
+//	COPY graph.person (id, properties) FROM STDIN
+//
+// The columns are separated by a tab, and backslashes, tabs, newlines, and
+// carriage returns in the properties are escaped with a backslash. Nil
+// properties are written as an empty object. CopyLine returns "" if v is NULL
+// or its properties cannot be marshaled, and the row must be skipped then. Use
+// AppendCopyLine to tell the two apart.
+func (v BasicVertex) CopyLine() string {
+	b, err := v.AppendCopyLine(nil)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// AppendCopyLine appends the line that CopyLine returns to buf and returns the
+// extended buffer. It returns buf unchanged if v is NULL, and an error if the
+// properties of v cannot be marshaled.
+func (v BasicVertex) AppendCopyLine(buf []byte) ([]byte, error) {
+	if !v.Valid {
+		return buf, nil
+	}
+
+	p, err := marshalCopyProperties(v.Properties)
+	if err != nil {
+		return buf, errors.New("invalid vertex properties: " + err.Error())
+	}
+	buf = v.Id.AppendTo(buf)
+	buf = append(buf, '\t')
+	return append(buf, p...), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. It reads
+// the text form returned by MarshalText, including "NULL".
+func (v *BasicVertex) UnmarshalText(b []byte) error {
+	if isNullElement(b) {
+		return ScanEntity(nil, v)
+	}
+	return ScanEntity(b, v)
+}
+
+// basicVertexFields is BasicVertex without its methods, so that encoding/json
+// encodes and decodes its fields.
+type basicVertexFields BasicVertex
+
+// MarshalJSON implements the json.Marshaler interface. It encodes v as a JSON
+// object of its fields rather than the text form of MarshalText, which
+// encoding/json would use otherwise.
+func (v BasicVertex) MarshalJSON() ([]byte, error) {
+	return json.Marshal(basicVertexFields(v))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It reads either the
+// JSON object returned by MarshalJSON or a JSON string of the text form.
+func (v *BasicVertex) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		err := json.Unmarshal(b, &s)
+		if err != nil {
+			return err
+		}
+		return v.UnmarshalText([]byte(s))
+	}
+	return json.Unmarshal(b, (*basicVertexFields)(v))
+}
+
 // Scan implements the database/sql Scanner interface. It calls ScanEntity.
 func (v *BasicVertex) Scan(src interface{}) error {
 	return ScanEntity(src, v)
@@ -190,7 +359,7 @@ func (a *basicVertexArray) Scan(src interface{}) error {
 		return fmt.Errorf("invalid source for _vertex: %v", b)
 	}
 
-	ds, err := readVertexElements(b)
+	ds, err := readVertexElements(b, scanOptions{})
 	if err != nil {
 		return errors.New("failed to read vertex elements: " + err.Error())
 	}
@@ -16,7 +16,16 @@ limitations under the License.
 
 package ag
 
-import "testing"
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 // ScanEntity - case nil
 func TestBasicVertexScanNil(t *testing.T) {
@@ -70,27 +79,992 @@ func TestBasicVertexScan(t *testing.T) {
 	}
 }
 
+// ScanEntity - case string
+func TestBasicVertexScanString(t *testing.T) {
+	src := `v[3.1]{"name": "go"}`
+	var v BasicVertex
+	err := v.Scan(src)
+	if err != nil {
+		t.Error(err)
+	} else if !v.Valid {
+		t.Errorf("got NULL, want Valid %T", v)
+	} else if name := v.Properties["name"]; name != "go" {
+		t.Errorf(`got %v, want "go"`, name)
+	}
+}
+
+func TestBasicVertexText(t *testing.T) {
+	tests := []string{
+		`v[3.1]{"name":"go","tags":["a","b"]}`,
+		"NULL",
+	}
+	for _, c := range tests {
+		var v BasicVertex
+		err := v.UnmarshalText([]byte(c))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		b, err := v.MarshalText()
+		if err != nil {
+			t.Error(err)
+		} else if string(b) != c {
+			t.Errorf("got %s, want %s", b, c)
+		}
+	}
+}
+
+func TestBasicVertexJSON(t *testing.T) {
+	var v BasicVertex
+	err := v.Scan(`person[3.1]{"name":"go"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) < 1 || b[0] != '{' {
+		t.Errorf("got %s, want a JSON object", b)
+	}
+
+	var x BasicVertex
+	err = json.Unmarshal(b, &x)
+	if err != nil {
+		t.Error(err)
+	} else if !x.Equal(v) {
+		t.Errorf("got %s, want %s", x, v)
+	}
+
+	err = json.Unmarshal([]byte(`"person[3.1]{\"name\":\"go\"}"`), &x)
+	if err != nil {
+		t.Error(err)
+	} else if !x.Equal(v) {
+		t.Errorf("got %s, want %s", x, v)
+	}
+}
+
+func TestBasicVertexJSONEmbedded(t *testing.T) {
+	var v BasicVertex
+	err := v.Scan(`person[3.1]{"name":"go"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The methods of BasicVertex are promoted, so Extra is dropped.
+	b, err := json.Marshal(struct {
+		BasicVertex
+		Extra string
+	}{v, "x"})
+	if err != nil {
+		t.Error(err)
+	} else if string(b) != string(want) {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	b, err = json.Marshal(struct {
+		V     BasicVertex
+		Extra string
+	}{v, "x"})
+	if err != nil {
+		t.Error(err)
+	} else if s := `{"V":` + string(want) + `,"Extra":"x"}`; string(b) != s {
+		t.Errorf("got %s, want %s", b, s)
+	}
+
+	b, err = json.Marshal(struct {
+		VertexHeader
+		Properties map[string]interface{}
+		Extra      string
+	}{v.VertexHeader, v.Properties, "x"})
+	if err != nil {
+		t.Error(err)
+	} else if !strings.Contains(string(b), `"Extra":"x"`) {
+		t.Errorf("got %s, want Extra", b)
+	}
+}
+
+func TestBasicVertexScanWhitespace(t *testing.T) {
+	tests := []string{
+		`person[3.1]{"a": 1}`,
+		`person[3.1] {"a": 1}`,
+		`person [ 3.1 ] {"a": 1}`,
+	}
+	for _, b := range tests {
+		var v BasicVertex
+		err := v.Scan(b)
+		if err != nil {
+			t.Error(err)
+		} else if s, want := v.String(), `person[3.1]{"a":1}`; s != want {
+			t.Errorf("got %s, want %s", s, want)
+		}
+
+		var p BasicPath
+		err = p.Scan("[" + b + "]")
+		if err != nil {
+			t.Error(err)
+		} else if len(p.Vertices) != 1 || p.Vertices[0].Label != "person" {
+			t.Errorf("got %s, want one person vertex", p)
+		}
+	}
+}
+
+func TestBasicVertexScanRawBytes(t *testing.T) {
+	src := sql.RawBytes(`v[3.1]{"name": "go"}`)
+
+	var v retainingVertex
+	err := ScanEntity(src, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The driver reuses src for the next row.
+	copy(src, `v[3.2]{"name": "ag"}`)
+	if string(v.raw) != `{"name": "go"}` {
+		t.Errorf("got %s, want properties unaffected by reuse", v.raw)
+	}
+
+	var p BasicPath
+	err = p.Scan(sql.RawBytes(`[v[3.1]{}]`))
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 type userVertex struct {
 	VertexHeader `json:"-"`
 	Name         string
 }
 
-func (v *userVertex) Scan(src interface{}) error {
-	return ScanEntity(src, v)
+func (v *userVertex) Scan(src interface{}) error {
+	return ScanEntity(src, v)
+}
+
+// saveEntityData - json.Unmarshal
+func TestPropertiesDefault(t *testing.T) {
+	b := []byte(`v[3.1]{"name": "go"}`)
+	var v userVertex
+	err := v.Scan(b)
+	if err != nil {
+		t.Error(err)
+	} else if !v.Valid {
+		t.Errorf("got NULL, want Valid %T", v)
+	} else if v.Name != "go" {
+		t.Errorf(`got %q, want "go"`, v.Name)
+	}
+}
+
+// checkTruncated calls scan with every proper prefix of s and reports an
+// error if scan panics or does not return an error.
+func checkTruncated(t *testing.T, s string, scan func(b []byte) error) {
+	t.Helper()
+	for i := 0; i < len(s); i++ {
+		b := []byte(s[:i])
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("panic for %q: %v", b, r)
+				}
+			}()
+			if err := scan(b); err == nil {
+				t.Errorf("error expected for %q", b)
+			}
+		}()
+	}
+}
+
+func TestBasicVertexScanTruncated(t *testing.T) {
+	const s = `person[3.1]{"name": "go}", "tags": [1, {"a": 2}]}`
+	checkTruncated(t, s, func(b []byte) error {
+		var v BasicVertex
+		return v.Scan(b)
+	})
+	checkTruncated(t, s, func(b []byte) error {
+		var v BasicVertex
+		_, err := ScanEntityN(b, &v)
+		return err
+	})
+	checkTruncated(t, "["+s+",NULL,"+s+"]", func(b []byte) error {
+		var vs []BasicVertex
+		return Array(&vs).Scan(b)
+	})
+	checkTruncated(t, "["+s+",NULL,"+s+"]", func(b []byte) error {
+		var vs []BasicVertex
+		return ScanEntities(b, &vs)
+	})
+}
+
+func TestScanEntityLenientProperties(t *testing.T) {
+	src := []byte(`person[3.1]{
+		// seed data
+		"name": "go",
+		"tags": ["a", "b",],
+	}`)
+
+	var v BasicVertex
+	err := ScanEntity(src, &v)
+	if err == nil {
+		t.Error("error expected for trailing commas by default")
+	}
+
+	err = ScanEntity(src, &v, LenientProperties(), Strict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Properties["name"] != "go" || len(v.Properties["tags"].([]interface{})) != 2 {
+		t.Errorf("got %v, want name and 2 tags", v.Properties)
+	}
+}
+
+func TestBasicVertexInto(t *testing.T) {
+	var v BasicVertex
+	err := v.Scan([]byte(`person[3.1]{"name": "go", "age": 15, "tags": ["a"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var p struct {
+		Name string
+		Age  int
+		Tags []string
+	}
+	err = v.Into(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "go" || p.Age != 15 || len(p.Tags) != 1 {
+		t.Errorf("got %+v, want {Name:go Age:15 Tags:[a]}", p)
+	}
+
+	var n struct{ Name int }
+	err = v.Into(&n)
+	if err == nil {
+		t.Error("error expected for mismatched type")
+	}
+	err = v.Into(nil)
+	if err == nil {
+		t.Error("error expected for nil")
+	}
+}
+
+// saveEntityData - strict
+func TestScanEntityStrict(t *testing.T) {
+	tests := []struct {
+		b   []byte
+		dup bool
+	}{
+		{[]byte(`v[3.1]{"a": 1, "b": {"a": 1}, "c": [{"a": 1}, {"a": 1}]}`), false},
+		{[]byte(`v[3.1]{"a": 1, "a": 2}`), true},
+		{[]byte(`v[3.1]{"o": {"a": 1, "a": 2}}`), true},
+		{[]byte(`v[3.1]{"l": [{"a": 1, "a": 2}]}`), true},
+	}
+	for _, c := range tests {
+		var v BasicVertex
+		err := ScanEntity(c.b, &v)
+		if err != nil {
+			t.Errorf("%s: %v", c.b, err)
+		}
+
+		err = ScanEntity(c.b, &v, Strict())
+		if c.dup && err == nil {
+			t.Errorf("error expected for %s", c.b)
+		} else if !c.dup && err != nil {
+			t.Errorf("%s: %v", c.b, err)
+		}
+	}
+}
+
+func TestScanEntityN(t *testing.T) {
+	b := []byte(`v[3.1]{"name": "{go}"},NULL,v[3.2]{}`)
+	want := []string{`v[3.1]{"name":"{go}"}`, "NULL", "v[3.2]{}"}
+	for i := 0; len(b) > 0; i++ {
+		if i > 0 {
+			// remove comma
+			b = b[1:]
+		}
+
+		var v BasicVertex
+		n, err := ScanEntityN(b, &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := v.String(); s != want[i] {
+			t.Errorf("got %s, want %s", s, want[i])
+		}
+		b = b[n:]
+	}
+}
+
+func TestScanEntityNError(t *testing.T) {
+	tests := [][]byte{
+		[]byte(nil),
+		[]byte("v[3.1]"),
+		[]byte(`v[3.1]{"name": "go"`),
+	}
+	for _, b := range tests {
+		var v BasicVertex
+		_, err := ScanEntityN(b, &v)
+		if err == nil {
+			t.Errorf("error expected for %s", b)
+		}
+	}
+}
+
+type requiredVertex struct {
+	userVertex
+}
+
+func (_ requiredVertex) RequiredProperties() []string {
+	return []string{"name", "age", "email"}
+}
+
+// saveEntityData - PropertiesRequirer
+func TestRequiredProperties(t *testing.T) {
+	var v requiredVertex
+	err := ScanEntity([]byte(`v[3.1]{"name": "go", "age": 15, "email": null}`), &v)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = ScanEntity([]byte(`v[3.1]{"name": "go"}`), &v)
+	if err == nil {
+		t.Error("error expected for missing properties")
+	} else if want := `missing required properties: "age", "email"`; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
+
+type nestingVertex struct {
+	VertexHeader `json:"-"`
+	Owner        NestedEntity
+	Friend       NestedEntity
+}
+
+func (v *nestingVertex) Scan(src interface{}) error {
+	return ScanEntity(src, v)
+}
+
+// NestedEntity
+func TestNestedEntity(t *testing.T) {
+	var owner userVertex
+	var friend BasicVertex
+	v := nestingVertex{Owner: NestedEntity{&owner}, Friend: NestedEntity{&friend}}
+
+	b := []byte(`v[3.1]{"owner": {"label": "v", "id": "3.2", "properties": {"name": "go"}}, "friend": null}`)
+	err := ScanEntity(b, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !owner.Valid || owner.Name != "go" {
+		t.Errorf(`got %v, want valid vertex named "go"`, owner)
+	}
+	if friend.Valid {
+		t.Errorf("got %s, want NULL", friend)
+	}
+
+	tests := []string{
+		`v[3.1]{"owner": 1}`,
+		`v[3.1]{"owner": "v[3.2]{}"}`,
+		`v[3.1]{"owner": {"label": "v", "id": "3.2"}}`,
+		`v[3.1]{"owner": {"label": "e", "id": "4.1", "start": "3.1", "end": "3.2", "properties": {}}}`,
+	}
+	for _, c := range tests {
+		err = ScanEntity([]byte(c), &v)
+		if err == nil {
+			t.Errorf("error expected for %s", c)
+		}
+	}
+}
+
+// saveEntityData - skip properties
+func TestScanEntitySkipProperties(t *testing.T) {
+	b := []byte(`v[3.1]{"name": "go", "name": [}`)
+	var v BasicVertex
+	err := ScanEntity(b, &v, SkipProperties(), Strict())
+	if err != nil {
+		t.Error(err)
+	} else if !v.Valid || v.Label != "v" || v.Id.String() != "3.1" {
+		t.Errorf("got %s, want v[3.1]", v)
+	} else if v.Properties != nil {
+		t.Errorf("got %v, want nil properties", v.Properties)
+	}
+}
+
+var benchmarkVertex = []byte(`v[3.1]{"name": "go", "tags": ["a", "b", "c"], "meta": {"created": "2025-01-01", "score": 0.5}}`)
+
+func BenchmarkScanEntity(b *testing.B) {
+	var v BasicVertex
+	for i := 0; i < b.N; i++ {
+		ScanEntity(benchmarkVertex, &v)
+	}
+}
+
+func BenchmarkScanEntitySkipProperties(b *testing.B) {
+	var v BasicVertex
+	for i := 0; i < b.N; i++ {
+		ScanEntity(benchmarkVertex, &v, SkipProperties())
+	}
+}
+
+func TestScanRowEntity(t *testing.T) {
+	db := openTestDB([]string{"id", "n", "m"},
+		[]driver.Value{[]byte("3.1"), []byte(`v[3.1]{"name": "go"}`), nil},
+	)
+	defer db.Close()
+
+	rows, err := db.Query(`MATCH (n) RETURN id(n) AS id, n, NULL AS m`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		t.Fatal(rows.Err())
+	}
+
+	var v BasicVertex
+	err = ScanRowEntity(rows, "n", &v)
+	if err != nil {
+		t.Error(err)
+	} else if !v.Valid || v.Properties["name"] != "go" {
+		t.Errorf(`got %s, want vertex named "go"`, v)
+	}
+
+	err = ScanRowEntity(rows, "m", &v)
+	if err != nil {
+		t.Error(err)
+	} else if v.Valid {
+		t.Errorf("got %s, want NULL", v)
+	}
+
+	err = ScanRowEntity(rows, "x", &v)
+	if err == nil {
+		t.Error("error expected for unknown column")
+	}
+
+	err = ScanRowEntity(rows, "id", &v)
+	if err == nil {
+		t.Error("error expected for non-vertex column")
+	}
+}
+
+func TestScanVertexProps(t *testing.T) {
+	var p struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	err := ScanVertexProps([]byte(`person[3.1]{"name": "ann", "age": 30, "x": true}`), &p)
+	if err != nil {
+		t.Error(err)
+	} else if p.Name != "ann" || p.Age != 30 {
+		t.Errorf("got %+v, want {Name:ann Age:30}", p)
+	}
+
+	err = ScanVertexProps(nil, &p)
+	if err != nil {
+		t.Error(err)
+	} else if p.Name != "ann" {
+		t.Errorf("got %+v, want unchanged", p)
+	}
+
+	tests := []struct {
+		src interface{}
+		dst interface{}
+	}{
+		{[]byte(`person[3.1]{"name": 1}`), &p},
+		{[]byte(`person{"name": "ann"}`), &p},
+		{[]byte(`person[3.1]{"name": "ann"}`), p},
+	}
+	for _, c := range tests {
+		err := ScanVertexProps(c.src, c.dst)
+		if err == nil {
+			t.Errorf("error expected for %s into %T", c.src, c.dst)
+		}
+	}
+}
+
+func TestAssembleEntity(t *testing.T) {
+	var v BasicVertex
+	err := AssembleEntity(VertexCore{"person", mustNewGraphId("3.1")}, []byte(`{"name": "ann"}`), &v)
+	if err != nil {
+		t.Error(err)
+	} else if s, want := v.String(), `person[3.1]{"name":"ann"}`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	var e BasicEdge
+	err = AssembleEntity(EdgeCore{"knows", mustNewGraphId("4.1"), mustNewGraphId("3.1"), mustNewGraphId("3.2")}, nil, &e)
+	if err != nil {
+		t.Error(err)
+	} else if s, want := e.String(), `knows[4.1][3.1,3.2]{}`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	tests := []struct {
+		core   interface{}
+		props  []byte
+		entity Entity
+	}{
+		{EdgeCore{}, nil, &BasicVertex{}},
+		{VertexCore{}, nil, &BasicEdge{}},
+		{"3.1", nil, &BasicVertex{}},
+		{VertexCore{"v", mustNewGraphId("3.1")}, []byte(`{"a"}`), &BasicVertex{}},
+	}
+	for _, c := range tests {
+		err := AssembleEntity(c.core, c.props, c.entity)
+		if err == nil {
+			t.Errorf("error expected for %T into %T", c.core, c.entity)
+		}
+	}
+}
+
+func TestCollectById(t *testing.T) {
+	db := openTestDB([]string{"n"},
+		[]driver.Value{[]byte(`v[3.1]{"name": "go"}`)},
+		[]driver.Value{nil},
+		[]driver.Value{[]byte(`v[3.2]{"name": "ag"}`)},
+	)
+	defer db.Close()
+
+	rows, err := db.Query(`MATCH (n) RETURN n`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	m, err := CollectById(rows, func() *BasicVertex { return new(BasicVertex) }, func(v *BasicVertex) GraphId { return v.Id })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 {
+		t.Fatalf("got %d vertices, want 2", len(m))
+	}
+	if v := m[mustNewGraphId("3.2")]; v == nil || v.Properties["name"] != "ag" {
+		t.Errorf(`got %v, want vertex named "ag"`, v)
+	}
+
+	db = openTestDB([]string{"n"}, []driver.Value{[]byte(`v[3.1]{`)})
+	defer db.Close()
+	rows, err = db.Query(`MATCH (n) RETURN n`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	_, err = CollectById(rows, func() *BasicVertex { return new(BasicVertex) }, func(v *BasicVertex) GraphId { return v.Id })
+	if err == nil {
+		t.Error("error expected for bad vertex")
+	}
+}
+
+type retainingVertex struct {
+	VertexHeader
+	raw []byte
+}
+
+func (v *retainingVertex) SaveProperties(b []byte) error {
+	v.raw = b
+	return nil
+}
+
+// saveEntityData - copy properties
+func TestScanEntityCopyProperties(t *testing.T) {
+	const props = `{"name": "go"}`
+
+	// The driver may reuse src for the next row.
+	src := []byte(`v[3.1]` + props)
+	var v retainingVertex
+	err := ScanEntity(src, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(src, `v[3.2]{"name": "ag"}`)
+	if string(v.raw) == props {
+		t.Errorf("got %s, want properties overwritten by reuse", v.raw)
+	}
+
+	src = []byte(`v[3.1]` + props)
+	err = ScanEntity(src, &v, CopyPropertiesOnSave())
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(src, `v[3.2]{"name": "ag"}`)
+	if string(v.raw) != props {
+		t.Errorf("got %s, want %s", v.raw, props)
+	}
+}
+
+func TestCopyProperties(t *testing.T) {
+	b := []byte(`{"name": "go"}`)
+	c := CopyProperties(b)
+	if string(c) != string(b) {
+		t.Errorf("got %s, want %s", c, b)
+	} else if &c[0] == &b[0] {
+		t.Error("CopyProperties references underlying array")
+	}
+
+	if c := CopyProperties(nil); c != nil {
+		t.Errorf("got %v, want nil", c)
+	}
+}
+
+var errTestSaveProperties = errors.New("bad properties")
+
+type failingVertex struct {
+	VertexHeader
+}
+
+func (v *failingVertex) SaveProperties(b []byte) error {
+	return errTestSaveProperties
+}
+
+// saveEntityData - error context
+func TestScanEntitySavePropertiesError(t *testing.T) {
+	var v failingVertex
+	err := ScanEntity([]byte(`person[3.1]{}`), &v)
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if !errors.Is(err, errTestSaveProperties) {
+		t.Errorf("got %v, want %v wrapped", err, errTestSaveProperties)
+	}
+	if want := "saving properties for person[3.1]: bad properties"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+
+	var e BasicEdge
+	err = ScanEntity([]byte(`knows[4.1][3.1,3.2]{"a": }`), &e)
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if want := "saving properties for knows[4.1]: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got %q, want prefix %q", err, want)
+	}
 }
 
-// saveEntityData - json.Unmarshal
-func TestPropertiesDefault(t *testing.T) {
-	b := []byte(`v[3.1]{"name": "go"}`)
-	var v userVertex
-	err := v.Scan(b)
+func TestSplitLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		graph string
+		name  string
+	}{
+		{"person", "", "person"},
+		{"social.person", "social", "person"},
+		{`"my.graph".person`, "my.graph", "person"},
+		{`social."per.son"`, "social", "per.son"},
+		{`"say ""hi"""`, "", `say "hi"`},
+	}
+	for _, c := range tests {
+		graph, name := SplitLabel(c.label)
+		if graph != c.graph || name != c.name {
+			t.Errorf("got SplitLabel(%q) == %q, %q, want %q, %q", c.label, graph, name, c.graph, c.name)
+		}
+	}
+}
+
+func TestScanEntityGraphQualifiedLabel(t *testing.T) {
+	tests := []struct {
+		b     string
+		label string
+	}{
+		{`person[3.1]{}`, "person"},
+		{`social.person[3.1]{}`, "social.person"},
+	}
+	for _, c := range tests {
+		var v BasicVertex
+		err := v.Scan(c.b)
+		if err != nil {
+			t.Error(err)
+		} else if v.Label != c.label {
+			t.Errorf("got %q, want %q", v.Label, c.label)
+		}
+	}
+
+	var e BasicEdge
+	err := e.Scan(`social.knows[4.1][3.1,3.2]{}`)
 	if err != nil {
 		t.Error(err)
-	} else if !v.Valid {
-		t.Errorf("got NULL, want Valid %T", v)
-	} else if v.Name != "go" {
+	} else if graph, name := SplitLabel(e.Label); graph != "social" || name != "knows" {
+		t.Errorf(`got %q, %q, want "social", "knows"`, graph, name)
+	}
+
+	var vs []BasicVertex
+	err = Array(&vs).Scan([]byte(`[social.person[3.1]{},person[3.2]{}]`))
+	if err != nil {
+		t.Error(err)
+	} else if vs[0].Label != "social.person" || vs[1].Label != "person" {
+		t.Errorf("got %q and %q, want social.person and person", vs[0].Label, vs[1].Label)
+	}
+}
+
+func TestPeekLabel(t *testing.T) {
+	tests := []struct {
+		b     string
+		label string
+	}{
+		{`person[3.1]{}`, "person"},
+		{`knows[4.1][3.1,3.2]{}`, "knows"},
+		{`"my[label]"[3.1]{}`, "my[label]"},
+		{`"say ""hi"""[3.1]{}`, `say "hi"`},
+	}
+	for _, c := range tests {
+		label, err := PeekLabel([]byte(c.b))
+		if err != nil {
+			t.Error(err)
+		} else if label != c.label {
+			t.Errorf("got %q, want %q", label, c.label)
+		}
+	}
+
+	bad := []string{"", "3.1", "[v[3.1]{}]", "NULL", `"open[3.1]`, `""[3.1]{}`}
+	for _, b := range bad {
+		_, err := PeekLabel([]byte(b))
+		if err == nil {
+			t.Errorf("error expected for %q", b)
+		}
+	}
+}
+
+func TestBasicVertexScanNewlines(t *testing.T) {
+	// jsonb escapes newlines in strings, and may have them between tokens.
+	const v = "v[3.1]{\"text\": \"line 1\\nline 2\\n}],\\r\\n\\tend\",\n \"n\":\r\n1}"
+	const want = "line 1\nline 2\n}],\r\n\tend"
+
+	var bv BasicVertex
+	err := bv.Scan([]byte(v))
+	if err != nil {
+		t.Error(err)
+	} else if s := bv.Properties["text"]; s != want {
+		t.Errorf("got %q, want %q", s, want)
+	}
+
+	var vs []BasicVertex
+	err = Array(&vs).Scan([]byte("[" + v + "," + v + "]"))
+	if err != nil {
+		t.Error(err)
+	} else if len(vs) != 2 || vs[1].Properties["text"] != want || vs[1].Properties["n"] != float64(1) {
+		t.Errorf("got %v, want 2 vertices with %q", vs, want)
+	}
+
+	var p BasicPath
+	err = p.Scan([]byte("[" + v + ",e[4.1][3.1,3.2]{\"s\": \"a\\nb\"}," + v + "]"))
+	if err != nil {
+		t.Error(err)
+	} else if p.Vertices[1].Properties["text"] != want || p.Edges[0].Properties["s"] != "a\nb" {
+		t.Errorf("got %s, want the strings intact", p)
+	}
+
+	// A raw newline in a string is not valid JSON; it must fail rather than
+	// be cut at the newline.
+	err = bv.Scan([]byte("v[3.1]{\"text\": \"line 1\nline 2\"}"))
+	if err == nil {
+		t.Errorf("error expected for a raw newline in a string, got %s", bv)
+	}
+}
+
+func TestBasicVertexCopyLine(t *testing.T) {
+	var v BasicVertex
+	err := v.Scan([]byte(`person[3.1]{"name": "a\tb", "bio": "line 1\nline 2", "path": "C:\\tmp"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "3.1\t" + `{"bio":"line 1\\nline 2","name":"a\\tb","path":"C:\\\\tmp"}`
+	if s := v.CopyLine(); s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	if strings.ContainsAny(want[4:], "\t\n\r") {
+		t.Errorf("got raw tab or newline in %q", want)
+	}
+
+	v.Properties = nil
+	if s, want := v.CopyLine(), "3.1\t{}"; s != want {
+		t.Errorf("got %q, want %q", s, want)
+	}
+
+	if s := (BasicVertex{}).CopyLine(); s != "" {
+		t.Errorf("got %q for NULL, want empty", s)
+	}
+	if b, err := (BasicVertex{}).AppendCopyLine([]byte("x")); err != nil || string(b) != "x" {
+		t.Errorf("got %q, %v for NULL, want buf unchanged", b, err)
+	}
+
+	v.Properties = map[string]interface{}{"f": math.NaN()}
+	if s := v.CopyLine(); s != "" {
+		t.Errorf("got %q for unmarshalable properties, want empty", s)
+	}
+	if _, err := v.AppendCopyLine(nil); err == nil {
+		t.Error("error expected for unmarshalable properties")
+	}
+}
+
+func TestDistinctLabels(t *testing.T) {
+	elements := [][]byte{
+		[]byte(`person[3.1]{"name": "a"}`),
+		nil,
+		[]byte("NULL"),
+		[]byte(`knows[4.1][3.1,3.2]{}`),
+		[]byte(`[person[3.1]{},likes[5.1][3.1,6.1]{},movie[6.1]{}]`),
+		[]byte(`[city[7.1]{},NULL,person[3.2]{}]`),
+		[]byte(`[{"label": "book", "id": "8.1", "properties": {}}]`),
+		[]byte(`[]`),
+	}
+	labels, err := DistinctLabels(elements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"book", "city", "knows", "likes", "movie", "person"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("got %v, want %v", labels, want)
+	}
+
+	if labels, err := DistinctLabels(nil); err != nil || len(labels) != 0 {
+		t.Errorf("got %v, %v, want none", labels, err)
+	}
+
+	for _, b := range []string{"3.1", "[3.1]", `[v[3.1]{}`} {
+		_, err := DistinctLabels([][]byte{[]byte(b)})
+		if err == nil {
+			t.Errorf("error expected for %s", b)
+		}
+	}
+}
+
+type metaVertex struct {
+	VertexHeader `json:"-"`
+	ReservedProperties
+	Name string
+}
+
+// saveEntityData - ReservedPropertiesSaver
+func TestReservedProperties(t *testing.T) {
+	var v metaVertex
+	err := ScanEntity([]byte(`v[3.1]{"name": "go", "__id__": 7, "_src": "remote"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "go" {
 		t.Errorf(`got %q, want "go"`, v.Name)
 	}
+	if len(v.Reserved) != 2 || v.Reserved["__id__"] != 7.0 || v.Reserved["_src"] != "remote" {
+		t.Errorf("got %v, want __id__ and _src", v.Reserved)
+	}
+
+	err = ScanEntity([]byte(`v[3.2]{"name": "ag"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Reserved == nil || len(v.Reserved) != 0 {
+		t.Errorf("got %v, want empty map", v.Reserved)
+	}
+}
+
+type personProps struct {
+	Age int `json:"age,omitempty"`
+}
+
+type driftVertex struct {
+	VertexHeader `json:"-"`
+	UnknownProperties
+	personProps
+	Name   string
+	Secret string `json:"-"`
+	hidden string
+}
+
+// saveEntityData - UnknownPropertiesSaver
+func TestUnknownProperties(t *testing.T) {
+	var v driftVertex
+	err := ScanEntity([]byte(`v[3.1]{"NAME": "go", "age": 3, "secret": "x", "hidden": "y", "zip": 1, "city": "a"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "go" || v.Age != 3 {
+		t.Errorf("got %+v, want Name go and Age 3", v)
+	}
+	if want := []string{"city", "hidden", "secret", "zip"}; !reflect.DeepEqual(v.Unknown, want) {
+		t.Errorf("got %v, want %v", v.Unknown, want)
+	}
+
+	err = ScanEntity([]byte(`v[3.2]{"name": "ag"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Unknown != nil {
+		t.Errorf("got %v, want nil", v.Unknown)
+	}
+}
+
+type shapeVertex struct {
+	VertexHeader `json:"-"`
+	Shape        interface{}
+}
+
+type circle struct {
+	R float64 `json:"r"`
+}
+
+type square struct {
+	Side float64 `json:"side"`
+}
+
+// saveEntityData - PropertiesRegistry
+func TestPropertiesRegistry(t *testing.T) {
+	r := NewPropertiesRegistry()
+	r.Register("circle", func(entity Entity, b []byte) error {
+		var c circle
+		err := json.Unmarshal(b, &c)
+		entity.(*shapeVertex).Shape = c
+		return err
+	})
+	r.Register("square", func(entity Entity, b []byte) error {
+		var s square
+		err := json.Unmarshal(b, &s)
+		entity.(*shapeVertex).Shape = s
+		return err
+	})
+	dec := NewDecoder(WithPropertiesRegistry(r))
+
+	tests := []struct {
+		src  string
+		want interface{}
+	}{
+		{`circle[3.1]{"r": 1}`, circle{1}},
+		{`square[4.1]{"side": 2}`, square{2}},
+		// unregistered labels are unmarshaled as usual
+		{`other[5.1]{"Shape": "x"}`, "x"},
+	}
+	for _, c := range tests {
+		var v shapeVertex
+		err := dec.ScanEntity([]byte(c.src), &v)
+		if err != nil {
+			t.Error(err)
+		} else if v.Shape != c.want {
+			t.Errorf("got %#v for %s, want %#v", v.Shape, c.src, c.want)
+		}
+	}
+
+	// PropertiesSaver wins
+	var bv BasicVertex
+	err := dec.ScanEntity([]byte(`circle[3.1]{"r": 1}`), &bv)
+	if err != nil {
+		t.Error(err)
+	} else if bv.Properties["r"] != float64(1) {
+		t.Errorf("got %v, want r", bv.Properties)
+	}
+
+	r.Register("circle", nil)
+	var v shapeVertex
+	err = dec.ScanEntity([]byte(`circle[3.1]{"Shape": 1}`), &v)
+	if err != nil {
+		t.Error(err)
+	} else if v.Shape != float64(1) {
+		t.Errorf("got %#v, want 1", v.Shape)
+	}
 }
 
 func TestBasicVertexArrayScanNil(t *testing.T) {
@@ -125,6 +1099,10 @@ var vertexArrayTests = []struct {
 	src interface{}
 	n   int
 }{
+	{
+		[]byte(`[null,NULLx[3.1]{"name": "go"}]`),
+		2,
+	},
 	{
 		[]byte(`[NULL,v[3.1]{"name": "go"},v[3.2]{"name": "go"}]`),
 		3,
@@ -178,6 +1156,99 @@ func TestUserVertexArrayScan(t *testing.T) {
 	}
 }
 
+type plainVertex struct {
+	VertexHeader `json:"-"`
+	Name         string
+}
+
+func TestScanEntities(t *testing.T) {
+	for _, c := range vertexArrayTests {
+		var vs []plainVertex
+		err := ScanEntities(c.src, &vs)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if n := len(vs); n != c.n {
+			t.Errorf("got len(vs) == %d, want %d", n, c.n)
+		} else if c.src == nil && vs != nil {
+			t.Errorf("got %v, want nil", vs)
+		}
+	}
+
+	var es []BasicEdge
+	err := ScanEntities(`[e[4.1][3.1,3.2]{}]`, &es)
+	if err != nil {
+		t.Error(err)
+	} else if len(es) != 1 || !es[0].Valid {
+		t.Errorf("got %v, want one edge", es)
+	}
+}
+
+func TestScanEntitiesError(t *testing.T) {
+	src := []byte(`[v[3.1]{}]`)
+	tests := []interface{}{
+		nil,
+		[]plainVertex{},
+		(*[]plainVertex)(nil),
+		&[1]plainVertex{},
+		&[]int{},
+		&[]*plainVertex{},
+	}
+	for _, out := range tests {
+		err := ScanEntities(src, out)
+		if err == nil {
+			t.Errorf("error expected for %T", out)
+		}
+	}
+
+	var vs []plainVertex
+	for _, src := range []interface{}{0, []byte{}, []byte(`[v[3.1]{"name": 1}]`)} {
+		err := ScanEntities(src, &vs)
+		if err == nil {
+			t.Errorf("error expected for %v", src)
+		}
+	}
+}
+
+func TestScanEntitiesPartial(t *testing.T) {
+	src := []byte(`[v[3.1]{"name": "a"},v[3.2]{"name": 2},NULL,v[3.4]{"name": "d"}]`)
+
+	var vs []plainVertex
+	errs, err := ScanEntitiesPartial(src, &vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 4 || len(errs) != 4 {
+		t.Fatalf("got %d elements and %d errors, want 4 and 4", len(vs), len(errs))
+	}
+	for i, e := range errs {
+		if (e != nil) != (i == 1) {
+			t.Errorf("got error %v for element %d", e, i)
+		}
+	}
+	if !vs[0].Valid || vs[0].Name != "a" || vs[1].Valid || vs[2].Valid || !vs[3].Valid || vs[3].Name != "d" {
+		t.Errorf("got %v, want [a NULL NULL d]", vs)
+	}
+
+	vs = nil
+	errs, err = ScanEntitiesPartial(`[v[3.1]{"name": "a"}]`, &vs)
+	if err != nil || errs != nil {
+		t.Errorf("got %v, %v, want nil, nil", errs, err)
+	}
+
+	_, err = ScanEntitiesPartial(`[v[3.1]{"name": "a"}`, &vs)
+	if err == nil {
+		t.Error("error expected for invalid array")
+	}
+
+	err = ScanEntities(src, &vs)
+	if err == nil {
+		t.Error("error expected for ScanEntities")
+	}
+}
+
 func TestServerVertex(t *testing.T) {
 	skipUnlessServerTest(t)
 
@@ -217,3 +1288,107 @@ func TestServerVertex(t *testing.T) {
 		t.Errorf("got %v, want NULL", vs[0])
 	}
 }
+
+// TestServerNestedEntity checks the JSON of a vertex nested in properties and
+// in a map, which NestedEntity and AgValue read.
+func TestServerNestedEntity(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	db := mustOpenAndSetGraph(t)
+	defer db.Close()
+
+	_, err := db.Exec(`CREATE (:neo {name: 'go'})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`MATCH (o:neo) CREATE (:nev {owner: o})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var oid GraphId
+	err = db.QueryRow(`MATCH (o:neo) RETURN id(o)`).Scan(&oid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var owner userVertex
+	v := nestingVertex{Owner: NestedEntity{&owner}, Friend: NestedEntity{&BasicVertex{}}}
+	err = db.QueryRow(`MATCH (n:nev) RETURN n`).Scan(&v)
+	if err != nil {
+		t.Error(err)
+	} else if !owner.Valid || owner.Label != "neo" || !owner.Id.Equal(oid) || owner.Name != "go" {
+		t.Errorf("got %v, want neo[%s] named \"go\"", owner, oid)
+	}
+
+	var a AgValue
+	err = db.QueryRow(`MATCH (o:neo) RETURN {owner: o}`).Scan(&a)
+	if err != nil {
+		t.Error(err)
+	} else if m, ok := a.Value.(map[string]interface{}); !ok {
+		t.Errorf("got %T, want map[string]interface{}", a.Value)
+	} else if o, ok := m["owner"].(BasicVertex); !ok || !o.Id.Equal(oid) {
+		t.Errorf("got %#v, want neo[%s]", m["owner"], oid)
+	}
+}
+
+// TestServerTextRoundTrip checks that what is read from the text form of
+// vertex and edge matches what is stored in the database exactly, to catch
+// changes of the text form between server versions.
+func TestServerTextRoundTrip(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	db := mustOpenAndSetGraph(t)
+	defer db.Close()
+
+	_, err := db.Exec(`CREATE (:rtv {name: 'go', n: 1, tags: ['a', 'b'], nested: {k: true}})-[:rte {w: 0.5}]->(:rtv {name: 'ag'})`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v BasicVertex
+	var e BasicEdge
+	var vid, eid, endId string
+	q := `MATCH (a:rtv {name: 'go'})-[r:rte]->(b:rtv) RETURN a, id(a)::text, r, id(r)::text, id(b)::text`
+	err = db.QueryRow(q).Scan(&v, &vid, &e, &eid, &endId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Label != "rtv" || v.Id.String() != vid {
+		t.Errorf("got %s[%s], want rtv[%s]", v.Label, v.Id, vid)
+	}
+	want := map[string]interface{}{
+		"name":   "go",
+		"n":      float64(1),
+		"tags":   []interface{}{"a", "b"},
+		"nested": map[string]interface{}{"k": true},
+	}
+	if !reflect.DeepEqual(v.Properties, want) {
+		t.Errorf("got %v, want %v", v.Properties, want)
+	}
+
+	if e.Label != "rte" || e.Id.String() != eid {
+		t.Errorf("got %s[%s], want rte[%s]", e.Label, e.Id, eid)
+	}
+	if e.Start.String() != vid || e.End.String() != endId {
+		t.Errorf("got [%s,%s], want [%s,%s]", e.Start, e.End, vid, endId)
+	}
+	if !reflect.DeepEqual(e.Properties, map[string]interface{}{"w": 0.5}) {
+		t.Errorf("got %v, want {w: 0.5}", e.Properties)
+	}
+
+	// The text form read back must be the same as the server writes.
+	var text string
+	err = db.QueryRow(`MATCH (a:rtv {name: 'go'}) RETURN a::text`).Scan(&text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v2 BasicVertex
+	err = v2.UnmarshalText([]byte(text))
+	if err != nil {
+		t.Error(err)
+	} else if !v2.Id.Equal(v.Id) || !reflect.DeepEqual(v2.Properties, v.Properties) {
+		t.Errorf("got %s, want %s", v2, v)
+	}
+}